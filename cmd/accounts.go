@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"io"
+	"strings"
+)
+
+func runAccounts(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("accounts")
+	_ = fs.Parse(args)
+
+	c, err := a.connect(ctx)
+	if err != nil {
+		return err
+	}
+	accounts, err := c.GetAccountsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.output(accounts.Data, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tIBAN\tPRODUCT\tHOLDER\tBALANCE")
+		for _, acc := range accounts.Data {
+			at := acc.Attributes
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", acc.Id, at.Iban, at.Product.DisplayName, at.HolderName, at.Balance)
+		}
+	})
+}
+
+func runCards(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("cards")
+	_ = fs.Parse(args)
+
+	c, err := a.connect(ctx)
+	if err != nil {
+		return err
+	}
+	cards, err := c.GetCreditCardsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.output(cards.Data, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tCARD\tPRODUCT\tSTATE\tBALANCE\tAVAILABLE")
+		for _, cc := range cards.Data {
+			at := cc.Attributes
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", cc.Id, at.MaskedPan, at.Product.DisplayName, at.State,
+				at.Balance.Money, at.AvailableLimit)
+		}
+	})
+}
+
+// balance is a row of the balances command
+type balance struct {
+	ID      string          `json:"id"`
+	Kind    string          `json:"kind"`
+	Name    string          `json:"name"`
+	Balance dkbclient.Money `json:"balance"`
+}
+
+func runBalances(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("balances")
+	_ = fs.Parse(args)
+
+	c, err := a.connect(ctx)
+	if err != nil {
+		return err
+	}
+	accounts, err := c.GetAccountsContext(ctx)
+	if err != nil {
+		return err
+	}
+	cards, err := c.GetCreditCardsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var balances []balance
+	for _, acc := range accounts.Data {
+		at := acc.Attributes
+		balances = append(balances, balance{ID: acc.Id, Kind: "account", Name: at.Iban, Balance: at.Balance})
+	}
+	for _, cc := range cards.Data {
+		at := cc.Attributes
+		balances = append(balances, balance{ID: cc.Id, Kind: "card", Name: at.MaskedPan, Balance: at.Balance.Money})
+	}
+
+	return a.output(balances, func(w io.Writer) {
+		fmt.Fprintln(w, "KIND\tNAME\tBALANCE")
+		for _, b := range balances {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", b.Kind, b.Name, b.Balance)
+		}
+	})
+}
+
+// transactionFlags are the flags selecting the transactions of an account or a card
+type transactionFlags struct {
+	account string
+	card    string
+	from    dateFlag
+	to      dateFlag
+	status  string
+	limit   int
+}
+
+// newTransactionFlags registers the flags selecting transactions on fs
+func newTransactionFlags(fs *flag.FlagSet) *transactionFlags {
+	tf := &transactionFlags{}
+	fs.StringVar(&tf.account, "account", "", "ID of the account")
+	fs.StringVar(&tf.card, "card", "", "ID of the card")
+	fs.Var(&tf.from, "from", "earliest booking date (YYYY-MM-DD)")
+	fs.Var(&tf.to, "to", "latest booking date (YYYY-MM-DD)")
+	fs.StringVar(&tf.status, "status", "", "only transactions with the given status, e.g. booked or pending")
+	fs.IntVar(&tf.limit, "limit", 0, "maximum number of transactions")
+	return tf
+}
+
+func (tf *transactionFlags) validate() error {
+	if (tf.account == "") == (tf.card == "") {
+		return errors.New("exactly one of -account and -card is required")
+	}
+	return nil
+}
+
+func (tf *transactionFlags) query() dkbclient.TransactionQuery {
+	return dkbclient.TransactionQuery{From: tf.from.t, To: tf.to.t, Status: dkbclient.TransactionStatus(tf.status), Limit: tf.limit}
+}
+
+func runTransactions(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("transactions")
+	tf := newTransactionFlags(fs)
+	_ = fs.Parse(args)
+	if err := tf.validate(); err != nil {
+		return err
+	}
+
+	c, err := a.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	if tf.account != "" {
+		at, err := c.QueryAccountTransactions(ctx, tf.account, tf.query())
+		if err != nil {
+			return err
+		}
+		return a.output(at.Data, func(w io.Writer) {
+			fmt.Fprintln(w, "BOOKED\tSTATUS\tAMOUNT\tCOUNTERPARTY\tDESCRIPTION")
+			for _, t := range at.Data {
+				ta := t.Attributes
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", ta.BookingDate, ta.Status, ta.Amount, counterparty(t), oneLine(ta.Description))
+			}
+		})
+	}
+
+	cct, err := c.QueryCreditCardTransactions(ctx, tf.card, tf.query())
+	if err != nil {
+		return err
+	}
+	return a.output(cct.Data, func(w io.Writer) {
+		fmt.Fprintln(w, "BOOKED\tSTATUS\tAMOUNT\tDESCRIPTION")
+		for _, t := range cct.Data {
+			ta := t.Attributes
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", ta.BookingDate, ta.Status, ta.Amount.Money, oneLine(ta.Description))
+		}
+	})
+}
+
+// counterparty returns the name of the other party of t: the creditor of outgoing and the debtor of incoming payments
+func counterparty(t dkbclient.AccountTransaction) string {
+	if t.Attributes.Amount.Sign() < 0 {
+		return t.Attributes.Creditor.Name
+	}
+	return t.Attributes.Debtor.Name
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
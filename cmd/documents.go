@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"github.com/pczora/dkbrobot/pkg/docsync"
+	"io"
+	"os"
+)
+
+func runDocuments(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errors.New("expected subcommand list or download")
+	}
+
+	switch args[0] {
+	case "list":
+		return runDocumentsList(ctx, a, args[1:])
+	case "download":
+		return runDocumentsDownload(ctx, a, args[1:])
+	}
+	return fmt.Errorf("unknown subcommand %q, expected list or download", args[0])
+}
+
+// documentQueryFlags registers the flags of a DocumentQuery on fs
+func documentQueryFlags(fs *flag.FlagSet) func() dkbclient.DocumentQuery {
+	docType := fs.String("type", "", "only documents of the given document type ID")
+	var from, to dateFlag
+	fs.Var(&from, "from", "earliest creation date (YYYY-MM-DD)")
+	fs.Var(&to, "to", "latest creation date (YYYY-MM-DD)")
+	card := fs.String("card", "", "only documents of the given card ID")
+	unread := fs.Bool("unread", false, "only documents not yet marked as read")
+
+	return func() dkbclient.DocumentQuery {
+		q := dkbclient.DocumentQuery{DocumentType: *docType, From: from.t, CardID: *card, UnreadOnly: *unread}
+		if !to.t.IsZero() {
+			// include the whole day
+			q.To = to.t.AddDate(0, 0, 1).Add(-1)
+		}
+		return q
+	}
+}
+
+func runDocumentsList(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("documents list")
+	query := documentQueryFlags(fs)
+	_ = fs.Parse(args)
+
+	c, err := a.connect(ctx)
+	if err != nil {
+		return err
+	}
+	docs, err := c.QueryDocuments(ctx, query())
+	if err != nil {
+		return err
+	}
+
+	return a.output(docs.Data, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tCREATED\tTYPE\tFILE")
+		for _, d := range docs.Data {
+			t := d.DocumentTypeID()
+			if d.DocumentType != nil {
+				t = d.DocumentType.Label()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.ID, d.Attributes.CreationDate.Format("2006-01-02"), t, d.Attributes.FileName)
+		}
+	})
+}
+
+func runDocumentsDownload(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("documents download")
+	query := documentQueryFlags(fs)
+	dir := fs.String("dir", ".", "directory to save the documents to")
+	pathTemplate := fs.String("template", docsync.DefaultPathTemplate, "template for the paths of the documents")
+	workers := fs.Int("workers", dkbclient.DefaultDownloadOptions.Workers, "number of concurrent downloads")
+	interval := fs.Duration("interval", dkbclient.DefaultDownloadOptions.Interval, "minimum time between the start of two downloads")
+	markRead := fs.Bool("mark-read", false, "mark saved documents as read in the mailbox")
+	_ = fs.Parse(args)
+
+	tmpl, err := docsync.ParsePathTemplate(*pathTemplate)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.connect(ctx)
+	if err != nil {
+		return err
+	}
+	docs, err := c.QueryDocuments(ctx, query())
+	if err != nil {
+		return err
+	}
+
+	opts := dkbclient.DownloadOptions{Workers: *workers, Interval: *interval}
+	paths, err := docsync.Download(ctx, c, docs.Data, *dir, tmpl, opts)
+	for _, d := range docs.Data {
+		p, ok := paths[d.ID]
+		if !ok {
+			continue
+		}
+		fmt.Println(p)
+		if *markRead {
+			if merr := c.MarkDocumentRead(ctx, d.ID); merr != nil {
+				fmt.Fprintf(os.Stderr, "marking %s as read failed: %v\n", p, merr)
+			}
+		}
+	}
+	var de dkbclient.DocumentErrors
+	if errors.As(err, &de) {
+		for _, e := range de {
+			fmt.Fprintln(os.Stderr, e)
+		}
+	}
+	return err
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"github.com/pczora/dkbrobot/pkg/export"
+	"io"
+	"os"
+	"strings"
+)
+
+func runExport(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errors.New("expected export format: csv, ofx, mt940, camt053, ledger or beancount")
+	}
+
+	switch args[0] {
+	case "csv":
+		return runExportCSV(ctx, a, args[1:])
+	case "ofx":
+		return runExportOFX(ctx, a, args[1:])
+	case "mt940":
+		return runExportStatement(ctx, a, "export mt940", export.WriteMT940, args[1:])
+	case "camt053":
+		return runExportStatement(ctx, a, "export camt053", func(w io.Writer, s export.Statement) error {
+			return export.WriteCAMT053(w, s, export.CAMTOptions{})
+		}, args[1:])
+	case "ledger", "hledger":
+		return runExportJournal(ctx, a, "export ledger", export.WriteLedger, args[1:])
+	case "beancount":
+		return runExportJournal(ctx, a, "export beancount", export.WriteBeancount, args[1:])
+	}
+	return fmt.Errorf("unknown export format %q", args[0])
+}
+
+// exportFlags are the flags shared by all export formats
+type exportFlags struct {
+	*transactionFlags
+	output string
+}
+
+func newExportFlags(fs *flag.FlagSet) *exportFlags {
+	ef := &exportFlags{transactionFlags: newTransactionFlags(fs)}
+	fs.StringVar(&ef.output, "o", "-", "output file, - for stdout")
+	return ef
+}
+
+// transactions returns the selected transactions in the common export form
+func (ef *exportFlags) transactions(ctx context.Context, c *dkbclient.Client) ([]export.Transaction, error) {
+	if ef.account != "" {
+		at, err := c.QueryAccountTransactions(ctx, ef.account, ef.query())
+		if err != nil {
+			return nil, err
+		}
+		return export.FromAccountTransactions(at.Data), nil
+	}
+
+	cct, err := c.QueryCreditCardTransactions(ctx, ef.card, ef.query())
+	if err != nil {
+		return nil, err
+	}
+	return export.FromCreditCardTransactions(cct.Data), nil
+}
+
+// findAccount returns the account with the given ID
+func findAccount(ctx context.Context, c *dkbclient.Client, id string) (dkbclient.Account, error) {
+	accounts, err := c.GetAccountsContext(ctx)
+	if err != nil {
+		return dkbclient.Account{}, err
+	}
+	for _, acc := range accounts.Data {
+		if acc.Id == id {
+			return acc, nil
+		}
+	}
+	return dkbclient.Account{}, fmt.Errorf("account %s: %w", id, dkbclient.ErrNotFound)
+}
+
+// findCreditCard returns the credit card with the given ID
+func findCreditCard(ctx context.Context, c *dkbclient.Client, id string) (dkbclient.CreditCard, error) {
+	cards, err := c.GetCreditCardsContext(ctx)
+	if err != nil {
+		return dkbclient.CreditCard{}, err
+	}
+	for _, cc := range cards.Data {
+		if cc.Id == id {
+			return cc, nil
+		}
+	}
+	return dkbclient.CreditCard{}, fmt.Errorf("card %s: %w", id, dkbclient.ErrNotFound)
+}
+
+// write calls fn with the output file, which is only created once fn is called
+func (ef *exportFlags) write(fn func(w io.Writer) error) error {
+	if ef.output == "-" {
+		return fn(os.Stdout)
+	}
+
+	f, err := os.Create(ef.output)
+	if err != nil {
+		return err
+	}
+	err = fn(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func runExportCSV(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("export csv")
+	ef := newExportFlags(fs)
+	columns := fs.String("columns", "", "comma separated list of columns (default: all)")
+	locale := fs.String("locale", "de", "number and date formatting: de or intl")
+	_ = fs.Parse(args)
+	if err := ef.validate(); err != nil {
+		return err
+	}
+
+	opts := export.CSVOptions{}
+	switch *locale {
+	case "de":
+		opts.Locale = export.LocaleGerman
+	case "intl":
+		opts.Locale = export.LocaleInternational
+	default:
+		return fmt.Errorf("unknown locale %q", *locale)
+	}
+	if *columns != "" {
+		cols, err := export.ParseColumns(*columns)
+		if err != nil {
+			return err
+		}
+		opts.Columns = cols
+	}
+
+	c, err := a.connect(ctx)
+	if err != nil {
+		return err
+	}
+	txs, err := ef.transactions(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	return ef.write(func(w io.Writer) error {
+		return export.WriteCSV(w, txs, opts)
+	})
+}
+
+func runExportOFX(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("export ofx")
+	ef := newExportFlags(fs)
+	_ = fs.Parse(args)
+	if err := ef.validate(); err != nil {
+		return err
+	}
+
+	c, err := a.connect(ctx)
+	if err != nil {
+		return err
+	}
+	txs, err := ef.transactions(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	if ef.account != "" {
+		acc, err := findAccount(ctx, c, ef.account)
+		if err != nil {
+			return err
+		}
+		return ef.write(func(w io.Writer) error {
+			return export.WriteAccountOFX(w, acc, txs, export.OFXOptions{})
+		})
+	}
+
+	cc, err := findCreditCard(ctx, c, ef.card)
+	if err != nil {
+		return err
+	}
+	return ef.write(func(w io.Writer) error {
+		return export.WriteCreditCardOFX(w, cc, txs, export.OFXOptions{})
+	})
+}
+
+// runExportStatement writes the account statement for the selected booking date range using write
+func runExportStatement(ctx context.Context, a *app, name string, write func(io.Writer, export.Statement) error,
+	args []string) error {
+	fs := a.flagSet(name)
+	ef := newExportFlags(fs)
+	number := fs.Int("number", 1, "statement number")
+	_ = fs.Parse(args)
+	if ef.account == "" || ef.card != "" {
+		return errors.New("-account is required, statements are not supported for cards")
+	}
+
+	c, err := a.connect(ctx)
+	if err != nil {
+		return err
+	}
+	acc, err := findAccount(ctx, c, ef.account)
+	if err != nil {
+		return err
+	}
+	// The balances are derived from the current one, which requires all transactions booked since -from
+	at, err := c.QueryAccountTransactions(ctx, ef.account, dkbclient.TransactionQuery{
+		From:   ef.from.t,
+		Status: dkbclient.TransactionStatusBooked,
+	})
+	if err != nil {
+		return err
+	}
+
+	s, err := export.NewStatement(acc, export.FromAccountTransactions(at.Data), ef.from.t, ef.to.t)
+	if err != nil {
+		return err
+	}
+	s.Number = *number
+	return ef.write(func(w io.Writer) error {
+		return write(w, s)
+	})
+}
+
+// accountsFlag is a flag.Value collecting journal account names given as KEY=NAME
+type accountsFlag map[string]string
+
+func (f accountsFlag) String() string {
+	var s []string
+	for k, v := range f {
+		s = append(s, k+"="+v)
+	}
+	return strings.Join(s, ",")
+}
+
+func (f accountsFlag) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || k == "" || v == "" {
+		return fmt.Errorf("invalid account name %q, expected IBAN=NAME or CARD=NAME", s)
+	}
+	f[strings.ReplaceAll(k, " ", "")] = v
+	return nil
+}
+
+// runExportJournal writes the selected transactions as plain text accounting journal using write
+func runExportJournal(ctx context.Context, a *app, name string,
+	write func(io.Writer, export.Journal, export.JournalOptions) error, args []string) error {
+	fs := a.flagSet(name)
+	ef := newExportFlags(fs)
+	accounts := accountsFlag{}
+	fs.Var(accounts, "account-name",
+		"journal account name of an IBAN, card ID or masked card number as KEY=NAME (repeatable)")
+	opts := export.JournalOptions{Accounts: accounts}
+	fs.StringVar(&opts.IncomeAccount, "income", export.DefaultIncomeAccount,
+		"account booked against for incoming transactions")
+	fs.StringVar(&opts.ExpenseAccount, "expenses", export.DefaultExpenseAccount,
+		"account booked against for outgoing transactions")
+	fs.StringVar(&opts.TransferAccount, "transfers", export.DefaultTransferAccount,
+		"clearing account booked against for transfers between accounts named by -account-name")
+	_ = fs.Parse(args)
+	if err := ef.validate(); err != nil {
+		return err
+	}
+
+	c, err := a.connect(ctx)
+	if err != nil {
+		return err
+	}
+	txs, err := ef.transactions(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	var j export.Journal
+	if ef.account != "" {
+		acc, err := findAccount(ctx, c, ef.account)
+		if err != nil {
+			return err
+		}
+		j = opts.AccountJournal(acc, txs)
+	} else {
+		cc, err := findCreditCard(ctx, c, ef.card)
+		if err != nil {
+			return err
+		}
+		j = opts.CreditCardJournal(cc, txs)
+	}
+	// The current balance only holds if all booked transactions are exported
+	if !ef.from.t.IsZero() || !ef.to.t.IsZero() || ef.status != "" || ef.limit > 0 {
+		j.Balance = nil
+	}
+
+	return ef.write(func(w io.Writer) error {
+		return write(w, j, opts)
+	})
+}
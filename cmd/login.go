@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"golang.org/x/term"
+	"os"
+	"syscall"
+)
+
+// login asks the user for their credentials and logs c in. Prompts are written to stderr, so that they do not mix
+// with the output of a command.
+func login(ctx context.Context, c *dkbclient.Client) error {
+	var username string
+	var password string
+
+	fmt.Fprintf(os.Stderr, "Username: ")
+	_, err := fmt.Scanf("%s", &username)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Password: ")
+	bytepw, err := term.ReadPassword(syscall.Stdin)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(os.Stderr, "\n")
+
+	password = string(bytepw)
+
+	return c.LoginContext(ctx, username, password, dkbclient.GetMostRecentlyEnrolledMFAMethod)
+}
+
+// mfaPrompt tells the user to approve the login on their MFA device
+type mfaPrompt struct {
+	dkbclient.NopMFAObserver
+}
+
+func (mfaPrompt) OnMethodSelected(m dkbclient.MFAMethod) {
+	if m.RequiresTAN() {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Please approve the login on device '%s'\n", m.Attributes.DeviceName)
+}
+
+func (mfaPrompt) OnApproved() {
+	fmt.Fprintln(os.Stderr, "Login approved")
+}
+
+// readTAN shows the challenge data and reads the TAN from stdin
+func readTAN(_ context.Context, m dkbclient.MFAMethod, ch dkbclient.MFAChallengeResponseData) (string, error) {
+	fmt.Fprintf(os.Stderr, "Challenge for '%s' (%s): %s\n", m.Attributes.DeviceName, m.Attributes.MethodType, ch.Attributes.Challenge)
+	fmt.Fprint(os.Stderr, "TAN: ")
+	var tan string
+	_, err := fmt.Scanf("%s", &tan)
+	if err != nil {
+		return "", err
+	}
+	return tan, nil
+}
@@ -1,72 +1,163 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/pczora/dkbrobot/pkg/dkbclient"
-	"golang.org/x/term"
 	"os"
-	"strings"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"syscall"
 )
 
+// command is a subcommand of the CLI
+type command struct {
+	usage string
+	run   func(ctx context.Context, a *app, args []string) error
+}
+
+var commands = map[string]command{
+	"login":          {"log in and save the session", runLogin},
+	"logout":         {"end the saved session", runLogout},
+	"accounts":       {"list accounts", runAccounts},
+	"cards":          {"list credit and debit cards", runCards},
+	"balances":       {"show the balances of all accounts and cards", runBalances},
+	"transactions":   {"list the transactions of an account or a card", runTransactions},
+	"documents":      {"list or download documents (documents list|download)", runDocuments},
+	"export":         {"export transactions (export csv|ofx|mt940|camt053|ledger|beancount)", runExport},
+	"sync-documents": {"save new and changed documents to a directory", runSyncDocuments},
+}
+
 func main() {
-	var username string
-	var password string
+	flag.Usage = usage
+	flag.Parse()
 
-	fmt.Printf("Username: ")
-	_, err := fmt.Scanf("%s", &username)
-	if err != nil {
-		panic(err)
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		usage()
+		os.Exit(2)
 	}
 
-	fmt.Printf("Password: ")
-	bytepw, err := term.ReadPassword(syscall.Stdin)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	a := &app{}
+	err := cmd.run(ctx, a, flag.Args()[1:])
+	if err == nil {
+		err = a.finish(ctx)
+	}
 	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", flag.Arg(0), err)
+		stop()
 		os.Exit(1)
 	}
-	fmt.Print("\n")
+}
+
+func usage() {
+	name := filepath.Base(os.Args[0])
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s <command> [flags]\n\nCommands:\n", name)
+	names := make([]string, 0, len(commands))
+	for n := range commands {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		fmt.Fprintf(flag.CommandLine.Output(), "  %-15s %s\n", n, commands[n].usage)
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "\nRun '%s <command> -h' for the flags of a command.\n", name)
+}
 
-	password = string(bytepw)
+// app holds the options shared by all commands and the logged in client
+type app struct {
+	sessionFile string
+	logout      bool
+	format      string
+	client      *dkbclient.Client
+}
 
-	c := dkbclient.New()
+// flagSet returns a FlagSet for the command name with the shared flags registered
+func (a *app) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(&a.sessionFile, "session", "", "file to save the session to and restore it from; the encryption key is read from $"+sessionKeyEnv)
+	fs.BoolVar(&a.logout, "logout", false, "log out and delete the session file when done")
+	fs.StringVar(&a.format, "format", formatText, "output format: text or json")
+	return fs
+}
 
-	err = c.Login(username, password, dkbclient.GetMostRecentlyEnrolledMFAMethod)
-	if err != nil {
-		panic(err)
+// connect returns a logged in client, restoring the saved session if possible
+func (a *app) connect(ctx context.Context) (*dkbclient.Client, error) {
+	if a.client != nil {
+		return a.client, nil
+	}
+	if a.format != formatText && a.format != formatJSON {
+		return nil, fmt.Errorf("unknown format %q", a.format)
 	}
 
-	//accounts, err := c.GetAccounts()
-	//if err != nil {
-	//	panic(err)
-	//}
-	//
-	//fmt.Printf("%+v", accounts)
-
-	documents, err := c.GetDocuments()
+	// a session that cannot be saved is checked before logging in, so that the MFA approval is not wasted
+	if a.sessionFile != "" && os.Getenv(sessionKeyEnv) == "" {
+		return nil, fmt.Errorf("-session requires $%s to be set", sessionKeyEnv)
+	}
 
+	c, err := dkbclient.NewWithOptions(dkbclient.WithMFAObserver(mfaPrompt{}), dkbclient.WithTANProvider(readTAN))
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	for _, d := range documents.Data {
-		fmt.Printf("%+v\n", d)
-		data, err := c.GetDocumentData(d.ID)
+	if !restoreSession(ctx, c, a.sessionFile) {
+		err = login(ctx, c)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
+	}
+	// the tokens may have been refreshed while restoring the session, so it is saved in either case
+	err = saveSession(c, a.sessionFile)
+	if err != nil {
+		return nil, fmt.Errorf("saving session: %w", err)
+	}
 
-		filename := d.Attributes.FileName
-		if !strings.HasSuffix(filename, ".pdf") {
-			filename = filename + ".pdf"
-		}
+	a.client = c
+	return c, nil
+}
 
-		f, err := os.Create(filename)
-		if err != nil {
-			panic(err)
-		}
-		f.Write(data)
-		f.Close()
+// finish ends the session if requested
+func (a *app) finish(ctx context.Context) error {
+	if !a.logout || a.client == nil {
+		return nil
+	}
+	return endSession(ctx, a.client, a.sessionFile)
+}
 
+func runLogin(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("login")
+	_ = fs.Parse(args)
+	if a.sessionFile == "" {
+		return errors.New("-session is required")
 	}
 
+	_, err := a.connect(ctx)
+	return err
+}
+
+func runLogout(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("logout")
+	_ = fs.Parse(args)
+	if a.sessionFile == "" {
+		return errors.New("-session is required")
+	}
+
+	c, err := dkbclient.NewWithOptions()
+	if err != nil {
+		return err
+	}
+	if !restoreSession(ctx, c, a.sessionFile) {
+		err = os.Remove(a.sessionFile)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	return endSession(ctx, c, a.sessionFile)
 }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"os"
+)
+
+const sessionKeyEnv = "DKBROBOT_SESSION_KEY"
+
+// restoreSession imports the session saved in path into c and reports whether it is still valid
+func restoreSession(ctx context.Context, c *dkbclient.Client, path string) bool {
+	if path == "" {
+		return false
+	}
+	key := os.Getenv(sessionKeyEnv)
+	if key == "" {
+		fmt.Fprintf(os.Stderr, "not restoring session: $%s is not set\n", sessionKeyEnv)
+		return false
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reading session failed: %v\n", err)
+		return false
+	}
+
+	err = c.ImportSession(data, []byte(key))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "restoring session failed: %v\n", err)
+		return false
+	}
+
+	_, err = c.GetAccountsContext(ctx)
+	if err != nil {
+		if !errors.Is(err, dkbclient.ErrUnauthorized) && !errors.Is(err, dkbclient.ErrReauthenticationRequired) {
+			fmt.Fprintf(os.Stderr, "checking session failed: %v\n", err)
+		}
+		return false
+	}
+	return true
+}
+
+// saveSession exports the session of c to path, which is only readable by the current user
+func saveSession(c *dkbclient.Client, path string) error {
+	if path == "" {
+		return nil
+	}
+	key := os.Getenv(sessionKeyEnv)
+	if key == "" {
+		return fmt.Errorf("$%s is not set", sessionKeyEnv)
+	}
+
+	data, err := c.ExportSession([]byte(key))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// endSession logs c out and removes the session file at path
+func endSession(ctx context.Context, c *dkbclient.Client, path string) error {
+	err := c.LogoutContext(ctx)
+	if path != "" {
+		if rerr := os.Remove(path); rerr != nil && !errors.Is(rerr, os.ErrNotExist) && err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
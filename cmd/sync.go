@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"github.com/pczora/dkbrobot/pkg/docsync"
+	"os"
+	"path/filepath"
+)
+
+// runSyncDocuments saves new and changed documents to a directory, keeping track of saved documents in a state file
+func runSyncDocuments(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("sync-documents")
+	dir := fs.String("dir", ".", "directory to save the documents to")
+	stateFile := fs.String("state", "", "state file (default: .dkbrobot-state.json in the directory)")
+	workers := fs.Int("workers", dkbclient.DefaultDownloadOptions.Workers, "number of concurrent downloads")
+	interval := fs.Duration("interval", dkbclient.DefaultDownloadOptions.Interval, "minimum time between the start of two downloads")
+	pathTemplate := fs.String("template", docsync.DefaultPathTemplate, "template for the paths of new documents, e.g. {{.Year}}/{{.Type}}/{{.StatementDate}}_{{.Subject}}.pdf")
+	markRead := fs.Bool("mark-read", false, "mark saved documents as read in the mailbox")
+	_ = fs.Parse(args)
+
+	tmpl, err := docsync.ParsePathTemplate(*pathTemplate)
+	if err != nil {
+		return err
+	}
+	if *stateFile == "" {
+		*stateFile = filepath.Join(*dir, ".dkbrobot-state.json")
+	}
+
+	err = os.MkdirAll(*dir, 0o755)
+	if err != nil {
+		return err
+	}
+	state, err := docsync.LoadState(*stateFile)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.connect(ctx)
+	if err != nil {
+		return err
+	}
+	documents, err := c.GetDocumentsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := dkbclient.DownloadOptions{Workers: *workers, Interval: *interval}
+	s := docsync.Syncer{Client: c, State: state, Dir: *dir, Template: tmpl, Download: &opts, MarkRead: *markRead}
+	report, err := s.Sync(ctx, documents.Data)
+
+	for _, e := range report.Added {
+		fmt.Printf("added %s\n", filepath.Join(*dir, e.Path))
+	}
+	for _, e := range report.Updated {
+		fmt.Printf("updated %s\n", filepath.Join(*dir, e.Path))
+	}
+	fmt.Printf("%d added, %d updated, %d unchanged, %d failed\n", len(report.Added), len(report.Updated), report.Unchanged, len(report.Failed))
+	for _, f := range report.Failed {
+		fmt.Fprintln(os.Stderr, f)
+	}
+
+	if err != nil {
+		return err
+	}
+	if len(report.Failed) > 0 {
+		return report.Failed
+	}
+	return nil
+}
@@ -2,6 +2,9 @@ module github.com/pczora/dkbrobot
 
 go 1.19
 
-require golang.org/x/term v0.16.0
+require (
+	golang.org/x/crypto v0.18.0
+	golang.org/x/term v0.16.0
+)
 
 require golang.org/x/sys v0.16.0 // indirect
@@ -2,12 +2,12 @@ package dkbclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"net/http/cookiejar"
 	"net/url"
 	"strings"
 	"time"
@@ -15,115 +15,133 @@ import (
 
 // Client is an HTTP client for the DKB web interface
 type Client struct {
-	httpClient                     *http.Client
-	xsrfToken                      string
-	mfaId                          string
-	accessToken                    string
+	httpClient  *http.Client
+	xsrfToken   string
+	mfaId       string
+	accessToken string
+	tokens      *tokenState
+	// authenticated is set once Login succeeded
+	authenticated bool
+	userAgent     string
+	logger        Logger
+	mfaObserver   MFAObserver
+	documentTypes *documentTypeCache
+	tanProvider   TANProvider
+	// BaseURL is the base URL all API endpoints are resolved against; DefaultBaseURL is used if empty
+	BaseURL                        string
 	VerificationStatusPollInterval time.Duration
 	VerificationStatusPollRetries  int
 }
 
-// New creates a new Client
+// New creates a new Client with default settings. It panics if the Client cannot be created.
+//
+// Deprecated: Use NewWithOptions, which returns an error instead of panicking.
 func New() Client {
-	jar, err := cookiejar.New(nil)
+	c, err := NewWithOptions()
 	if err != nil {
 		panic(err)
 	}
-	httpClient := &http.Client{Jar: jar, CheckRedirect: func(req *http.Request, via []*http.Request) error {
-		fmt.Println("redirect")
-		fmt.Println(req.URL)
-		return nil
-	}}
-
-	return Client{httpClient: httpClient, VerificationStatusPollInterval: 3000 * time.Millisecond, VerificationStatusPollRetries: 60}
+	return *c
 }
 
 type MfaMethodSelector func(methods []MFAMethod) (MFAMethod, error)
 
 // Login logs in to the DKB website using the provided credentials
 func (c *Client) Login(username, password string, mfaMethodSelector MfaMethodSelector) error {
+	return c.LoginContext(context.Background(), username, password, mfaMethodSelector)
+}
+
+// LoginContext is like Login, but aborts the login, including waiting for the MFA approval, once ctx is done
+func (c *Client) LoginContext(ctx context.Context, username, password string, mfaMethodSelector MfaMethodSelector) error {
+	c.authenticated = false
 
-	xsrfToken, err := c.getXsrfToken()
+	xsrfToken, err := c.getXsrfToken(ctx)
 
 	if err != nil {
 		return err
 	}
 	c.xsrfToken = xsrfToken
 
-	err = c.postLoginCredentials(username, password)
+	err = c.postLoginCredentials(ctx, username, password)
 	if err != nil {
 		return err
 	}
 
-	r, err := c.newRequest(http.MethodGet, "https://banking.dkb.de/api/mfa/mfa/methods?filter%5BmethodType%5D=seal_one", nil)
+	u, err := c.endpointURL(endpointMFAMethods, nil)
 	if err != nil {
 		return err
 	}
-	resp, err := c.httpClient.Do(r)
+	mfaMethodResponse := MFAMethodsResponse{}
+	err = get(ctx, c, u, &mfaMethodResponse)
 	if err != nil {
 		return err
 	}
 
-	buf := new(bytes.Buffer)
-	_, err = buf.ReadFrom(resp.Body)
+	selectedMethod, err := mfaMethodSelector(mfaMethodResponse.Data)
 	if err != nil {
 		return err
 	}
-	mfaMethodResponse := MFAMethodsResponse{}
+	c.mfaObserver.OnMethodSelected(selectedMethod)
 
-	err = json.Unmarshal(buf.Bytes(), &mfaMethodResponse)
+	if selectedMethod.RequiresTAN() && c.tanProvider == nil {
+		return fmt.Errorf("%w: MFA method type %s", ErrTANProviderRequired, selectedMethod.Attributes.MethodType)
+	}
+
+	ch := newMFAChallenge(selectedMethod, c.mfaId)
+	chb, _ := json.Marshal(ch)
+	u, err = c.endpointURL(endpointMFAChallenges, nil)
 	if err != nil {
 		return err
 	}
-
-	// TODO: Allow for selection of method to be used
-	selectedMethod, err := mfaMethodSelector(mfaMethodResponse.Data)
+	r, err := c.newRequest(ctx, http.MethodPost, u, bytes.NewReader(chb))
 	if err != nil {
 		return err
 	}
-	fmt.Printf("%+v\n", selectedMethod)
-
-	ch := newMFAChallenge(selectedMethod.ID, c.mfaId)
-	chb, _ := json.Marshal(ch)
-	r, err = c.newRequest(http.MethodPost, "https://banking.dkb.de/api/mfa/mfa/challenges", bytes.NewReader(chb))
 	r.Header.Set("Content-Type", "application/vnd.api+json")
-	resp, err = c.httpClient.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
-	b, _ := io.ReadAll(resp.Body)
-
-	cr := MFAChallengeResponse{}
-	err = json.Unmarshal(b, &cr)
+	b, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
 
-	err = c.pollVerificationStatus(cr.Data.ID)
+	cr := MFAChallengeResponse{}
+	err = json.Unmarshal(b, &cr)
 	if err != nil {
 		return err
 	}
 
-	err = c.postToken()
-	if err != nil {
-		return err
+	c.mfaObserver.OnChallengeCreated(cr.Data)
+
+	if selectedMethod.RequiresTAN() {
+		err = c.answerMFAChallenge(ctx, selectedMethod, cr.Data)
+		if err != nil {
+			return fmt.Errorf("answering MFA challenge: %w", err)
+		}
+	} else {
+		err = c.pollVerificationStatus(ctx, cr.Data.ID)
+		if err != nil {
+			return fmt.Errorf("waiting for MFA approval: %w", err)
+		}
 	}
+	c.mfaObserver.OnApproved()
 
-	r, err = c.newRequest(http.MethodGet, "https://banking.dkb.de/api/accounts/accounts", nil)
-	r.Header.Set("Content-Type", "application/vnd.api+json")
-	resp, err = c.httpClient.Do(r)
+	err = c.postToken(ctx)
 	if err != nil {
 		return err
 	}
-	b, _ = io.ReadAll(resp.Body)
-	fmt.Printf("%+v", string(b))
+	c.authenticated = true
+
 	return nil
 }
 
 // TODO: Naming (or refactoring)
 // currently this does more than just posting credentials: it also sets `c.mfa_id` and `c.accessToken`
-func (c *Client) postLoginCredentials(username string, password string) error {
+func (c *Client) postLoginCredentials(ctx context.Context, username string, password string) error {
 	data := url.Values{}
 
 	data.Add("grant_type", "banking_user_sca")
@@ -131,17 +149,23 @@ func (c *Client) postLoginCredentials(username string, password string) error {
 	data.Add("username", username)
 	data.Add("password", password)
 
-	r, err := c.newRequest(http.MethodPost, "https://banking.dkb.de/api/token", strings.NewReader(data.Encode()))
+	u, err := c.endpointURL(endpointToken, nil)
+	if err != nil {
+		return err
+	}
+	r, err := c.newRequest(ctx, http.MethodPost, u, strings.NewReader(data.Encode()))
 	if err != nil {
 		return err
 	}
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := c.httpClient.Do(r)
+	resp, err := c.do(r)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
-	fmt.Println(resp.Status)
+	c.logger.Printf("posting login credentials: %s", resp.Status)
 
 	buf := new(bytes.Buffer)
 
@@ -163,46 +187,98 @@ func (c *Client) postLoginCredentials(username string, password string) error {
 	return nil
 }
 
-func (c *Client) postToken() error {
+func (c *Client) postToken(ctx context.Context) error {
 	data := url.Values{}
 
 	data.Add("grant_type", "banking_user_mfa")
 	data.Add("mfa_id", c.mfaId)
 	data.Add("access_token", c.accessToken)
 
-	r, err := c.newRequest(http.MethodPost, "https://banking.dkb.de/api/token", strings.NewReader(data.Encode()))
+	u, err := c.endpointURL(endpointToken, nil)
 	if err != nil {
 		return err
 	}
-
-	resp, err := c.httpClient.Do(r)
+	r, err := c.newRequest(ctx, http.MethodPost, u, strings.NewReader(data.Encode()))
 	if err != nil {
 		return err
 	}
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.send(r)
+	if err != nil {
+		return fmt.Errorf("POSTing token failed: %w", err)
+	}
+	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("POSTing token failed: %v", err)
+	buf := new(bytes.Buffer)
+	_, err = buf.ReadFrom(resp.Body)
+	if err != nil {
+		return err
 	}
+	tokenData := TokenData{}
+	err = json.Unmarshal(buf.Bytes(), &tokenData)
+	if err != nil {
+		return err
+	}
+
+	c.tokens.mu.Lock()
+	c.setTokenData(tokenData)
+	c.tokens.mu.Unlock()
+
 	return nil
 }
 
-// newRequest wraps http.NewRequest and adds the `x-xsrf-token` header
-func (c *Client) newRequest(method string, url string, body io.Reader) (*http.Request, error) {
-	r, err := http.NewRequest(method, url, body)
+// newRequest wraps http.NewRequestWithContext and adds the `x-xsrf-token` header
+func (c *Client) newRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
+	r, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
 	r.Header.Set("x-xsrf-token", c.xsrfToken)
+	if c.userAgent != "" {
+		r.Header.Set("User-Agent", c.userAgent)
+	}
 	return r, nil
 }
 
-func (c *Client) getXsrfToken() (string, error) {
-	_, err := c.httpClient.Get("https://banking.dkb.de/login")
+// do sends r using the HTTP client of c like send, but refreshes the access token first if it is about to expire
+func (c *Client) do(r *http.Request) (*http.Response, error) {
+	if c.authenticated {
+		if err := c.refreshIfNeeded(r.Context()); err != nil {
+			return nil, err
+		}
+	}
+	return c.send(r)
+}
+
+// send sends r using the HTTP client of c. Responses with a status code other than 2xx are returned as an *APIError.
+func (c *Client) send(r *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, newAPIError(resp, c.authenticated)
+	}
+	return resp, nil
+}
+
+func (c *Client) getXsrfToken(ctx context.Context) (string, error) {
+	l, err := c.endpointURL(endpointLogin, nil)
 	if err != nil {
 		return "", err
 	}
+	r, err := c.newRequest(ctx, http.MethodGet, l, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.do(r)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
 
-	u, err := url.Parse("https://banking.dkb.de")
+	u, err := c.baseURL()
 	if err != nil {
 		return "", err
 	}
@@ -222,77 +298,109 @@ func (c *Client) getXsrfToken() (string, error) {
 	}
 	return t, nil
 }
-func (c *Client) pollVerificationStatus(cid string) error {
+
+func (c *Client) pollVerificationStatus(ctx context.Context, cid string) error {
 	pollID := time.Now().UTC().UnixMilli() * 1000
-	pollURL := "https://banking.dkb.de/api/mfa/mfa/challenges/" + cid
+	pollURL, err := c.endpointURL(endpointMFAChallenges, nil, cid)
+	if err != nil {
+		return err
+	}
 
-	req, err := http.NewRequest(http.MethodGet, pollURL, nil)
+	req, err := c.newRequest(ctx, http.MethodGet, pollURL, nil)
 	if err != nil {
 		return err
 	}
-	// TODO: Return error if verification failed after c.VerificationStatusPollRetries retries
 	for i := 0; i < c.VerificationStatusPollRetries; i++ {
 
 		pollID++
 
-		resp, _ := c.httpClient.Do(req)
-
-		b, _ := io.ReadAll(resp.Body)
+		resp, err := c.do(req)
+		if err != nil {
+			return err
+		}
 
-		cr := MFAChallengeResponse{}
-		err := json.Unmarshal(b, &cr)
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
 		if err != nil {
 			return err
 		}
 
-		err = resp.Body.Close()
+		cr := MFAChallengeResponse{}
+		err = json.Unmarshal(b, &cr)
 		if err != nil {
 			return err
 		}
 
-		if cr.Data.Attributes.VerificationStatus == "processed" {
+		status := cr.Data.Attributes.VerificationStatus
+		c.mfaObserver.OnPoll(status, i+1)
+		switch status {
+		case VerificationStatusProcessed, VerificationStatusAuthorized:
+			return nil
+		case VerificationStatusPending, "":
+		default:
+			if err := status.Err(); err != nil {
+				return err
+			}
+			return fmt.Errorf("%w %q", ErrMFAUnknownStatus, status)
+		}
+		if i == c.VerificationStatusPollRetries-1 {
 			break
 		}
-		time.Sleep(c.VerificationStatusPollInterval)
+
+		t := time.NewTimer(c.VerificationStatusPollInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
 	}
 
-	return nil
+	return ErrMFATimeout
 }
 
 // get uses the client c to perform a GET request to the provided URL; parsing it into a K
 // Since go does not support type parameters in methods, this is implemented as a function, instead of a method of Client
-func get[K any](c *Client, url string, dst *K) error {
-	req, err := c.newRequest(http.MethodGet, url, nil)
+func get[K any](ctx context.Context, c *Client, url string, dst *K) error {
+	req, err := c.newRequest(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
-	b, _ := io.ReadAll(resp.Body)
-
-	err = json.Unmarshal(b, &dst)
+	b, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
 
-	err = resp.Body.Close()
+	err = json.Unmarshal(b, &dst)
 	if err != nil {
-		return err
+		return fmt.Errorf("decoding response of %s: %w", url, err)
 	}
 
 	return nil
 }
 
+// GetAccounts returns all accounts of the logged in user
 func (c *Client) GetAccounts() (Accounts, error) {
-	u := "https://banking.dkb.de/api/accounts/accounts"
+	return c.GetAccountsContext(context.Background())
+}
+
+// GetAccountsContext is like GetAccounts, but uses ctx for the underlying requests
+func (c *Client) GetAccountsContext(ctx context.Context) (Accounts, error) {
+	u, err := c.endpointURL(endpointAccounts, nil)
+	if err != nil {
+		return Accounts{}, err
+	}
 
 	var a Accounts
 
-	err := get(c, u, &a)
+	err = get(ctx, c, u, &a)
 	if err != nil {
 		return Accounts{}, err
 	}
@@ -300,12 +408,25 @@ func (c *Client) GetAccounts() (Accounts, error) {
 	return a, nil
 }
 
+// GetCreditCards returns all credit and debit cards of the logged in user
 func (c *Client) GetCreditCards() (CreditCards, error) {
-	u := "https://banking.dkb.de/api/credit-card/cards?filter%5Btype%5D=creditCard&filter%5Bportfolio%5D=dkb&filter%5Btype%5D=debitCard"
+	return c.GetCreditCardsContext(context.Background())
+}
+
+// GetCreditCardsContext is like GetCreditCards, but uses ctx for the underlying requests
+func (c *Client) GetCreditCardsContext(ctx context.Context) (CreditCards, error) {
+	q := url.Values{
+		"filter[type]":      {"creditCard", "debitCard"},
+		"filter[portfolio]": {"dkb"},
+	}
+	u, err := c.endpointURL(endpointCreditCards, q)
+	if err != nil {
+		return CreditCards{}, err
+	}
 
 	var cc CreditCards
 
-	err := get(c, u, &cc)
+	err = get(ctx, c, u, &cc)
 	if err != nil {
 		return CreditCards{}, err
 	}
@@ -313,95 +434,127 @@ func (c *Client) GetCreditCards() (CreditCards, error) {
 	return cc, nil
 }
 
+// GetAccountTransactions returns all transactions of the account identified by accountID
 func (c *Client) GetAccountTransactions(accountID string) (AccountTransactions, error) {
-	u := "https://banking.dkb.de/api/accounts/accounts/" + accountID + "/transactions"
+	return c.GetAccountTransactionsContext(context.Background(), accountID)
+}
 
-	var at AccountTransactions
+// GetAccountTransactionsContext is like GetAccountTransactions, but uses ctx for the underlying requests
+func (c *Client) GetAccountTransactionsContext(ctx context.Context, accountID string) (AccountTransactions, error) {
+	return c.QueryAccountTransactions(ctx, accountID, TransactionQuery{})
+}
 
-	err := get(c, u, &at)
+// QueryAccountTransactions returns the transactions of the account identified by accountID matching q
+func (c *Client) QueryAccountTransactions(ctx context.Context, accountID string, q TransactionQuery) (AccountTransactions, error) {
+	at, err := collect(c.IterateAccountTransactions(ctx, accountID, q))
 	if err != nil {
 		return AccountTransactions{}, err
 	}
 
-	return at, nil
+	return AccountTransactions{Data: at}, nil
 }
 
+// IterateAccountTransactions returns an iterator over the transactions of the account identified by accountID
+// matching q, following the pagination of the API
+func (c *Client) IterateAccountTransactions(ctx context.Context, accountID string, q TransactionQuery) *AccountTransactionIterator {
+	u, err := c.endpointURL(endpointAccounts, q.values(), accountID, "transactions")
+	if err != nil {
+		return &AccountTransactionIterator{err: err}
+	}
+
+	return newTransactionIterator[AccountTransaction](ctx, c, u, q)
+}
+
+// GetCreditCardTransactions returns all transactions of the card identified by creditCardID
 func (c *Client) GetCreditCardTransactions(creditCardID string) (CreditCardTransactions, error) {
-	u := "https://banking.dkb.de/api/credit-card/cards/" + creditCardID + "/transactions"
+	return c.GetCreditCardTransactionsContext(context.Background(), creditCardID)
+}
 
-	var cct CreditCardTransactions
+// GetCreditCardTransactionsContext is like GetCreditCardTransactions, but uses ctx for the underlying requests
+func (c *Client) GetCreditCardTransactionsContext(ctx context.Context, creditCardID string) (CreditCardTransactions, error) {
+	return c.QueryCreditCardTransactions(ctx, creditCardID, TransactionQuery{})
+}
 
-	err := get(c, u, &cct)
+// QueryCreditCardTransactions returns the transactions of the card identified by creditCardID matching q
+func (c *Client) QueryCreditCardTransactions(ctx context.Context, creditCardID string, q TransactionQuery) (CreditCardTransactions, error) {
+	cct, err := collect(c.IterateCreditCardTransactions(ctx, creditCardID, q))
 	if err != nil {
 		return CreditCardTransactions{}, err
 	}
 
-	return cct, nil
+	return CreditCardTransactions{Data: cct}, nil
 }
 
-func (c *Client) GetDocuments() (Documents, error) {
-	u := "https://banking.dkb.de/api/documentstorage/documents?page%5Blimit%5D=1000"
-
-	var d Documents
-
-	err := get(c, u, &d)
+// IterateCreditCardTransactions returns an iterator over the transactions of the card identified by creditCardID
+// matching q, following the pagination of the API. The card transactions endpoint does not support filtering, so q
+// is applied client-side.
+func (c *Client) IterateCreditCardTransactions(ctx context.Context, creditCardID string, q TransactionQuery) *CreditCardTransactionIterator {
+	u, err := c.endpointURL(endpointCreditCards, nil, creditCardID, "transactions")
 	if err != nil {
-		return Documents{}, err
+		return &CreditCardTransactionIterator{err: err}
 	}
 
-	return d, nil
+	return newTransactionIterator[CreditCardTransaction](ctx, c, u, q)
 }
 
-func (c *Client) GetDocumentData(id string) ([]byte, error) {
-	dURL := "https://banking.dkb.de/api/documentstorage/documents/" + id
-	req, err := c.newRequest(http.MethodGet, dURL, nil)
-	req.Header.Set("Accept", "application/pdf")
+// GetDocuments returns the documents in the document storage of the logged in user
+func (c *Client) GetDocuments() (Documents, error) {
+	return c.GetDocumentsContext(context.Background())
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
+// GetDocumentsContext is like GetDocuments, but uses ctx for the underlying requests
+func (c *Client) GetDocumentsContext(ctx context.Context) (Documents, error) {
+	return c.QueryDocuments(ctx, DocumentQuery{})
+}
 
-	b, err := io.ReadAll(resp.Body)
+// GetDocumentData returns the content of the document identified by id
+func (c *Client) GetDocumentData(id string) ([]byte, error) {
+	return c.GetDocumentDataContext(context.Background(), id)
+}
+
+// GetDocumentDataContext is like GetDocumentData, but uses ctx for the underlying requests. The content is verified
+// like by DownloadDocument.
+func (c *Client) GetDocumentDataContext(ctx context.Context, id string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := c.DownloadDocument(ctx, id, buf)
 	if err != nil {
 		return nil, err
 	}
 
-	return b, nil
-
+	return buf.Bytes(), nil
 }
 
 // TODO: Move models to proper package
 
 type Documents struct {
-	Data []Document `json:"data"`
+	Data  []Document `json:"data"`
+	Links Links      `json:"links,omitempty"`
 }
 
 type Document struct {
-	ID    string `json:"id"`
-	Type  string `json:"type"`
-	Links struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// DocumentType is the resolved type of the document, set by QueryDocuments and IterateDocuments
+	DocumentType *DocumentType `json:"documentType,omitempty"`
+	Links        struct {
 		Self string `json:"self"`
 	} `json:"links"`
 	Attributes struct {
-		CreationDate    time.Time `json:"creationDate"`
-		ExpirationDate  string    `json:"expirationDate"`
-		RetentionPeriod string    `json:"retentionPeriod"`
-		ContentType     string    `json:"contentType"`
-		Checksum        string    `json:"checksum"`
-		FileName        string    `json:"fileName"`
-		Metadata        struct {
-			CardID            string `json:"cardId"`
-			StatementDate     string `json:"statementDate"`
-			StatementAmount   string `json:"statementAmount"`
-			Subject           string `json:"subject"`
-			StatementID       string `json:"statementID"`
-			StatementCurrency string `json:"statementCurrency"`
-		} `json:"metadata"`
-		Owner string `json:"owner"`
+		CreationDate    time.Time        `json:"creationDate"`
+		ExpirationDate  string           `json:"expirationDate"`
+		RetentionPeriod string           `json:"retentionPeriod"`
+		ContentType     string           `json:"contentType"`
+		Checksum        string           `json:"checksum"`
+		FileName        string           `json:"fileName"`
+		Metadata        DocumentMetadata `json:"metadata"`
+		Owner           string           `json:"owner"`
 	} `json:"attributes"`
 	Relationships struct {
 		DocumentType struct {
+			Data struct {
+				Type string `json:"type"`
+				ID   string `json:"id"`
+			} `json:"data,omitempty"`
 			Links struct {
 				Self    string `json:"self"`
 				Related string `json:"related"`
@@ -410,6 +563,50 @@ type Document struct {
 	} `json:"relationships"`
 }
 
+// DocumentMetadata describes the statement a document contains, if any
+type DocumentMetadata struct {
+	CardID        string
+	StatementDate string
+	// StatementAmount is the amount of the statement in the statement currency
+	StatementAmount Money
+	Subject         string
+	StatementID     string
+}
+
+// documentMetadataJSON is the representation of DocumentMetadata in the API, which has the statement amount and
+// currency as separate fields
+type documentMetadataJSON struct {
+	CardID            string          `json:"cardId"`
+	StatementDate     string          `json:"statementDate"`
+	StatementAmount   json.RawMessage `json:"statementAmount,omitempty"`
+	Subject           string          `json:"subject"`
+	StatementID       string          `json:"statementID"`
+	StatementCurrency string          `json:"statementCurrency"`
+}
+
+// MarshalJSON encodes m in the form used by the API
+func (m DocumentMetadata) MarshalJSON() ([]byte, error) {
+	j := documentMetadataJSON{CardID: m.CardID, StatementDate: m.StatementDate, Subject: m.Subject,
+		StatementID: m.StatementID, StatementCurrency: m.StatementAmount.Currency}
+	if value := m.StatementAmount.json().Value; value != "" {
+		j.StatementAmount, _ = json.Marshal(value)
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes m from the form used by the API
+func (m *DocumentMetadata) UnmarshalJSON(data []byte) error {
+	var j documentMetadataJSON
+	err := json.Unmarshal(data, &j)
+	if err != nil {
+		return err
+	}
+	*m = DocumentMetadata{CardID: j.CardID, StatementDate: j.StatementDate, Subject: j.Subject,
+		StatementID: j.StatementID}
+	m.StatementAmount, err = parseMoneyJSON(j.StatementAmount, j.StatementCurrency)
+	return err
+}
+
 type MFAMethodsResponse struct {
 	Data []MFAMethod `json:"data"`
 }
@@ -432,12 +629,28 @@ type MFAMethod struct {
 	} `json:"attributes"`
 }
 
+// GetMostRecentlyEnrolledMFAMethod selects the most recently enrolled unlocked seal_one method, i.e. push approval
+// in the DKB app. Only if there is none, the most recently enrolled unlocked method of another type is selected.
 func GetMostRecentlyEnrolledMFAMethod(methods []MFAMethod) (MFAMethod, error) {
-	if len(methods) == 0 {
+	var sealOne, other []MFAMethod
+	for _, m := range methods {
+		switch {
+		case m.Attributes.Locked:
+		case m.Attributes.MethodType == MFAMethodTypeSealOne:
+			sealOne = append(sealOne, m)
+		default:
+			other = append(other, m)
+		}
+	}
+	candidates := sealOne
+	if len(candidates) == 0 {
+		candidates = other
+	}
+	if len(candidates) == 0 {
 		return MFAMethod{}, fmt.Errorf("no MFAMethods available")
 	}
-	mre := methods[0]
-	for _, m := range methods {
+	mre := candidates[0]
+	for _, m := range candidates {
 		if m.Attributes.EnrolledAt.After(mre.Attributes.EnrolledAt) {
 			mre = m
 		}
@@ -448,7 +661,7 @@ func GetMostRecentlyEnrolledMFAMethod(methods []MFAMethod) (MFAMethod, error) {
 func UserSelectMFAMethod(methods []MFAMethod) (MFAMethod, error) {
 	var selection int
 	for i, m := range methods {
-		fmt.Printf("%d. '%s', enrolled at %s\n", i+1, m.Attributes.DeviceName, m.Attributes.EnrolledAt)
+		fmt.Printf("%d. '%s' (%s), enrolled at %s\n", i+1, m.Attributes.DeviceName, m.Attributes.MethodType, m.Attributes.EnrolledAt)
 	}
 	fmt.Print("Selection: ")
 	_, err := fmt.Scanf("%d", &selection)
@@ -456,8 +669,8 @@ func UserSelectMFAMethod(methods []MFAMethod) (MFAMethod, error) {
 		return MFAMethod{}, err
 	}
 
-	if selection < 1 || selection > len(methods)+1 {
-		return MFAMethod{}, fmt.Errorf("invalid selection %d, valid range: 1-%d", selection, len(methods)+1)
+	if selection < 1 || selection > len(methods) {
+		return MFAMethod{}, fmt.Errorf("invalid selection %d, valid range: 1-%d", selection, len(methods))
 	}
 	return methods[selection-1], nil
 }
@@ -472,16 +685,17 @@ type MFAChallengeData struct {
 }
 
 type MFAChallengeDataAttributes struct {
-	MethodID   string `json:"methodId"`
-	MethodType string `json:"methodType"`
-	MfaID      string `json:"mfaId"`
+	MethodID          string `json:"methodId,omitempty"`
+	MethodType        string `json:"methodType"`
+	MfaID             string `json:"mfaId,omitempty"`
+	ChallengeResponse string `json:"challengeResponse,omitempty"`
 }
 
-func newMFAChallenge(methodId string, mfaID string) MFAChallenge {
+func newMFAChallenge(method MFAMethod, mfaID string) MFAChallenge {
 	return MFAChallenge{Data: MFAChallengeData{Type: "mfa-challenge",
 		Attributes: MFAChallengeDataAttributes{
-			MethodID:   methodId,
-			MethodType: "seal_one",
+			MethodID:   method.ID,
+			MethodType: method.Attributes.MethodType,
 			MfaID:      mfaID,
 		}}}
 }
@@ -495,10 +709,12 @@ type MFAChallengeResponseData struct {
 	Type       string `json:"type"`
 	ID         string `json:"id"`
 	Attributes struct {
-		MfaID              string `json:"mfaId"`
-		MethodID           string `json:"methodId"`
-		MethodType         string `json:"methodType"`
-		VerificationStatus string `json:"verificationStatus"`
+		MfaID              string             `json:"mfaId"`
+		MethodID           string             `json:"methodId"`
+		MethodType         string             `json:"methodType"`
+		VerificationStatus VerificationStatus `json:"verificationStatus"`
+		// Challenge holds the method specific challenge data (e.g. a chipTAN flicker code) to be shown to the user
+		Challenge json.RawMessage `json:"challenge,omitempty"`
 	} `json:"attributes"`
 	Relationships struct {
 		Method struct {
@@ -541,14 +757,14 @@ type AccountAttributes struct {
 	Iban                              string           `json:"iban"`
 	Permissions                       []string         `json:"permissions"`
 	CurrencyCode                      string           `json:"currencyCode"`
-	Balance                           CurrencyValue    `json:"balance"`
-	AvailableBalance                  CurrencyValue    `json:"availableBalance"`
-	NearTimeBalance                   CurrencyValue    `json:"nearTimeBalance"`
+	Balance                           Money            `json:"balance"`
+	AvailableBalance                  Money            `json:"availableBalance"`
+	NearTimeBalance                   Money            `json:"nearTimeBalance"`
 	Product                           Product          `json:"product"`
 	State                             string           `json:"state"`
 	UpdatedAt                         string           `json:"updatedAt"`
 	OpeningDate                       string           `json:"openingDate"`
-	OverdraftLimit                    string           `json:"overdraftLimit"`
+	OverdraftLimit                    Money            `json:"overdraftLimit"`
 	OverdraftInterestRate             string           `json:"overdraftInterestRate,omitempty"`
 	InterestRate                      string           `json:"interestRate"`
 	UnauthorizedOverdraftInterestRate string           `json:"unauthorizedOverdraftInterestRate"`
@@ -556,9 +772,29 @@ type AccountAttributes struct {
 	ReferenceAccount                  ReferenceAccount `json:"referenceAccount,omitempty"`
 }
 
-type CurrencyValue struct {
-	CurrencyCode string `json:"currencyCode"`
-	Value        string `json:"value"`
+// MarshalJSON encodes a in the form used by the API, which has the overdraft limit as plain value in the account
+// currency
+func (a AccountAttributes) MarshalJSON() ([]byte, error) {
+	type attributes AccountAttributes
+	return json.Marshal(struct {
+		attributes
+		OverdraftLimit string `json:"overdraftLimit,omitempty"`
+	}{attributes(a), a.OverdraftLimit.json().Value})
+}
+
+// UnmarshalJSON decodes a from the form used by the API, taking the currency of the overdraft limit from the account
+func (a *AccountAttributes) UnmarshalJSON(data []byte) error {
+	type attributes AccountAttributes
+	j := struct {
+		*attributes
+		OverdraftLimit json.RawMessage `json:"overdraftLimit"`
+	}{attributes: (*attributes)(a)}
+	err := json.Unmarshal(data, &j)
+	if err != nil {
+		return err
+	}
+	a.OverdraftLimit, err = parseMoneyJSON(j.OverdraftLimit, a.CurrencyCode)
+	return err
 }
 
 type ReferenceAccount struct {
@@ -573,7 +809,8 @@ type Product struct {
 }
 
 type AccountTransactions struct {
-	Data []AccountTransaction `json:"data"`
+	Data  []AccountTransaction `json:"data"`
+	Links Links                `json:"links,omitempty"`
 }
 
 type AccountTransaction struct {
@@ -583,14 +820,14 @@ type AccountTransaction struct {
 }
 
 type AccountTransactionAttributes struct {
-	Status                  string        `json:"status"`
-	BookingDate             string        `json:"bookingDate"`
-	Description             string        `json:"description"`
-	EndToEndId              string        `json:"endToEndId,omitempty"`
-	TransactionType         string        `json:"transactionType"`
-	PurposeCode             string        `json:"purposeCode,omitempty"`
-	BusinessTransactionCode string        `json:"businessTransactionCode"`
-	Amount                  CurrencyValue `json:"amount"`
+	Status                  string `json:"status"`
+	BookingDate             string `json:"bookingDate"`
+	Description             string `json:"description"`
+	EndToEndId              string `json:"endToEndId,omitempty"`
+	TransactionType         string `json:"transactionType"`
+	PurposeCode             string `json:"purposeCode,omitempty"`
+	BusinessTransactionCode string `json:"businessTransactionCode"`
+	Amount                  Money  `json:"amount"`
 	Creditor                struct {
 		Name            string `json:"name,omitempty"`
 		CreditorAccount struct {
@@ -632,19 +869,15 @@ type CreditCard struct {
 	Type       string `json:"type"`
 	Id         string `json:"id"`
 	Attributes struct {
-		MaskedPan      string `json:"maskedPan"`
-		Network        string `json:"network"`
-		EngravedLine1  string `json:"engravedLine1"`
-		EngravedLine2  string `json:"engravedLine2,omitempty"`
-		ActivationDate string `json:"activationDate,omitempty"`
-		ExpiryDate     string `json:"expiryDate"`
-		Balance        struct {
-			Date         string `json:"date"`
-			CurrencyCode string `json:"currencyCode"`
-			Value        string `json:"value"`
-		} `json:"balance,omitempty"`
-		State string `json:"state"`
-		Owner struct {
+		MaskedPan      string  `json:"maskedPan"`
+		Network        string  `json:"network"`
+		EngravedLine1  string  `json:"engravedLine1"`
+		EngravedLine2  string  `json:"engravedLine2,omitempty"`
+		ActivationDate string  `json:"activationDate,omitempty"`
+		ExpiryDate     string  `json:"expiryDate"`
+		Balance        Balance `json:"balance,omitempty"`
+		State          string  `json:"state"`
+		Owner          struct {
 			FirstName  string `json:"firstName"`
 			LastName   string `json:"lastName"`
 			Title      string `json:"title"`
@@ -665,26 +898,9 @@ type CreditCard struct {
 			Id             string `json:"id"`
 			Type           string `json:"type"`
 		} `json:"product"`
-		Limit struct {
-			CurrencyCode string `json:"currencyCode,omitempty"`
-			Value        string `json:"value,omitempty"`
-			Identifier   string `json:"identifier,omitempty"`
-			Categories   []struct {
-				Name   string `json:"name"`
-				Amount struct {
-					CurrencyCode string `json:"currencyCode"`
-					Value        string `json:"value"`
-				} `json:"amount"`
-			} `json:"categories,omitempty"`
-		} `json:"limit"`
-		AvailableLimit struct {
-			CurrencyCode string `json:"currencyCode"`
-			Value        string `json:"value"`
-		} `json:"availableLimit,omitempty"`
-		AuthorizedAmount struct {
-			CurrencyCode string `json:"currencyCode"`
-			Value        string `json:"value"`
-		} `json:"authorizedAmount,omitempty"`
+		Limit            Limit `json:"limit"`
+		AvailableLimit   Money `json:"availableLimit,omitempty"`
+		AuthorizedAmount Money `json:"authorizedAmount,omitempty"`
 		ReferenceAccount struct {
 			Iban string `json:"iban"`
 			Bic  string `json:"bic"`
@@ -718,7 +934,8 @@ type CreditCard struct {
 }
 
 type CreditCardTransactions struct {
-	Data []CreditCardTransaction `json:"data"`
+	Data  []CreditCardTransaction `json:"data"`
+	Links Links                   `json:"links,omitempty"`
 }
 
 type CreditCardTransaction struct {
@@ -728,16 +945,9 @@ type CreditCardTransaction struct {
 }
 
 type CreditCardTransactionAttributes struct {
-	Amount struct {
-		ConversionRate string `json:"conversionRate"`
-		CurrencyCode   string `json:"currencyCode"`
-		Value          string `json:"value"`
-	} `json:"amount"`
-	CardId         string `json:"cardId"`
-	MerchantAmount struct {
-		CurrencyCode string `json:"currencyCode"`
-		Value        string `json:"value"`
-	} `json:"merchantAmount"`
+	Amount           ConvertedAmount `json:"amount"`
+	CardId           string          `json:"cardId"`
+	MerchantAmount   Money           `json:"merchantAmount"`
 	MerchantCategory struct {
 		Code string `json:"code"`
 	} `json:"merchantCategory,omitempty"`
@@ -0,0 +1,265 @@
+package dkbclient
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// documentsPageLimit is the number of documents requested per page
+const documentsPageLimit = 1000
+
+// DocumentQuery restricts the documents returned by QueryDocuments. The zero value matches all documents.
+type DocumentQuery struct {
+	// DocumentType restricts the documents to the given document type ID (see Document.DocumentTypeID), if not empty
+	DocumentType string
+	// From and To restrict the creation date to the given range, both inclusive. Zero values leave the range open.
+	From time.Time
+	To   time.Time
+	// CardID restricts the documents to those belonging to the given card, if not empty
+	CardID string
+	// UnreadOnly restricts the documents to those not yet marked as read in the mailbox
+	UnreadOnly bool
+}
+
+func (q DocumentQuery) matches(d Document, read map[string]bool) bool {
+	if q.DocumentType != "" && d.DocumentTypeID() != q.DocumentType {
+		return false
+	}
+	if !q.From.IsZero() && d.Attributes.CreationDate.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && d.Attributes.CreationDate.After(q.To) {
+		return false
+	}
+	if q.CardID != "" && d.Attributes.Metadata.CardID != q.CardID {
+		return false
+	}
+	if q.UnreadOnly && read[d.ID] {
+		return false
+	}
+	return true
+}
+
+// DocumentTypeID returns the ID of the type of d, taken from its documentType relationship
+func (d Document) DocumentTypeID() string {
+	if id := d.Relationships.DocumentType.Data.ID; id != "" {
+		return id
+	}
+	if rel := d.Relationships.DocumentType.Links.Related; rel != "" {
+		if u, err := url.Parse(rel); err == nil {
+			return path.Base(u.Path)
+		}
+	}
+	return ""
+}
+
+// QueryDocuments returns the documents in the document storage matching q, following the pagination of the API
+func (c *Client) QueryDocuments(ctx context.Context, q DocumentQuery) (Documents, error) {
+	d, err := collect(c.IterateDocuments(ctx, q))
+	if err != nil {
+		return Documents{}, err
+	}
+
+	return Documents{Data: d}, nil
+}
+
+// IterateDocuments returns an iterator over the documents in the document storage matching q, following the
+// pagination of the API. All filters are applied client-side; for UnreadOnly, the mailbox is requested first.
+func (c *Client) IterateDocuments(ctx context.Context, q DocumentQuery) *DocumentIterator {
+	u, err := c.endpointURL(endpointDocuments, url.Values{"page[limit]": {strconv.Itoa(documentsPageLimit)}})
+	if err != nil {
+		return &DocumentIterator{err: err}
+	}
+
+	var read map[string]bool
+	if q.UnreadOnly {
+		read, err = c.readState(ctx)
+		if err != nil {
+			return &DocumentIterator{err: err}
+		}
+	}
+
+	it := newIterator[Document](ctx, c, u)
+	it.keep = func(d Document) bool { return q.matches(d, read) }
+	it.prepare = c.resolveDocumentType
+	return it
+}
+
+type Messages struct {
+	Data  []Message `json:"data"`
+	Links Links     `json:"links,omitempty"`
+}
+
+// Message is the mailbox entry of a document, sharing its ID
+type Message struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Attributes struct {
+		DocumentType string    `json:"documentType"`
+		Subject      string    `json:"subject"`
+		CreationDate time.Time `json:"creationDate"`
+		Read         bool      `json:"read"`
+		Archived     bool      `json:"archived"`
+	} `json:"attributes"`
+}
+
+// GetMessages returns the mailbox entries of all documents
+func (c *Client) GetMessages(ctx context.Context) (Messages, error) {
+	u, err := c.endpointURL(endpointMessages, nil)
+	if err != nil {
+		return Messages{}, err
+	}
+
+	m, err := collect(newIterator[Message](ctx, c, u))
+	if err != nil {
+		return Messages{}, err
+	}
+
+	return Messages{Data: m}, nil
+}
+
+// readState returns whether a document has been read, by document ID
+func (c *Client) readState(ctx context.Context) (map[string]bool, error) {
+	m, err := c.GetMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	read := make(map[string]bool, len(m.Data))
+	for _, msg := range m.Data {
+		read[msg.ID] = msg.Attributes.Read
+	}
+	return read, nil
+}
+
+type DocumentTypes struct {
+	Data  []DocumentType `json:"data"`
+	Links Links          `json:"links,omitempty"`
+}
+
+// DocumentType is the type of a document, e.g. an account statement or a tax certificate
+type DocumentType struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Attributes struct {
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+		Category    string `json:"category"`
+	} `json:"attributes"`
+}
+
+// Label returns the display name of t, falling back to its name and ID
+func (t DocumentType) Label() string {
+	switch {
+	case t.Attributes.DisplayName != "":
+		return t.Attributes.DisplayName
+	case t.Attributes.Name != "":
+		return t.Attributes.Name
+	}
+	return t.ID
+}
+
+// documentTypeCache holds the document types known to a Client by ID
+type documentTypeCache struct {
+	mu     sync.Mutex
+	loaded bool
+	types  map[string]DocumentType
+}
+
+// GetDocumentTypes returns all document types
+func (c *Client) GetDocumentTypes(ctx context.Context) (DocumentTypes, error) {
+	u, err := c.endpointURL(endpointDocumentTypes, nil)
+	if err != nil {
+		return DocumentTypes{}, err
+	}
+
+	t, err := collect(newIterator[DocumentType](ctx, c, u))
+	if err != nil {
+		return DocumentTypes{}, err
+	}
+
+	c.documentTypes.mu.Lock()
+	defer c.documentTypes.mu.Unlock()
+	c.documentTypes.loaded = true
+	c.documentTypes.types = make(map[string]DocumentType, len(t))
+	for _, dt := range t {
+		c.documentTypes.types[dt.ID] = dt
+	}
+
+	return DocumentTypes{Data: t}, nil
+}
+
+// resolveDocumentType sets d.DocumentType. The document types are requested once per Client; types missing from
+// the list are requested through the related link of the document. Resolving is best effort: if a request fails, the
+// error is logged and the type is set to its ID only, so that listing documents does not fail.
+func (c *Client) resolveDocumentType(ctx context.Context, d *Document) error {
+	id := d.DocumentTypeID()
+	if id == "" {
+		return nil
+	}
+
+	c.documentTypes.mu.Lock()
+	loaded := c.documentTypes.loaded
+	c.documentTypes.mu.Unlock()
+	if !loaded {
+		if _, err := c.GetDocumentTypes(ctx); err != nil {
+			c.logger.Printf("requesting document types: %v", err)
+			c.documentTypes.mu.Lock()
+			c.documentTypes.loaded = true
+			if c.documentTypes.types == nil {
+				c.documentTypes.types = map[string]DocumentType{}
+			}
+			c.documentTypes.mu.Unlock()
+		}
+	}
+
+	c.documentTypes.mu.Lock()
+	dt, ok := c.documentTypes.types[id]
+	c.documentTypes.mu.Unlock()
+	if !ok {
+		var err error
+		dt, err = c.getRelatedDocumentType(ctx, *d)
+		if err != nil {
+			c.logger.Printf("requesting document type %s: %v", id, err)
+			dt = DocumentType{ID: id}
+		}
+		c.documentTypes.mu.Lock()
+		c.documentTypes.types[id] = dt
+		c.documentTypes.mu.Unlock()
+	}
+
+	d.DocumentType = &dt
+	return nil
+}
+
+// getRelatedDocumentType requests the type of d through its related link
+func (c *Client) getRelatedDocumentType(ctx context.Context, d Document) (DocumentType, error) {
+	base, err := c.baseURL()
+	if err != nil {
+		return DocumentType{}, err
+	}
+	rel, err := url.Parse(d.Relationships.DocumentType.Links.Related)
+	if err != nil {
+		return DocumentType{}, err
+	}
+	if rel.String() == "" {
+		return DocumentType{ID: d.DocumentTypeID()}, nil
+	}
+
+	var r struct {
+		Data DocumentType `json:"data"`
+	}
+	err = get(ctx, c, base.ResolveReference(rel).String(), &r)
+	if errors.Is(err, ErrNotFound) {
+		return DocumentType{ID: d.DocumentTypeID()}, nil
+	}
+	if err != nil {
+		return DocumentType{}, err
+	}
+	return r.Data, nil
+}
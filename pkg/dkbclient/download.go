@@ -0,0 +1,164 @@
+package dkbclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const defaultDocumentContentType = "application/pdf"
+
+var (
+	// ErrChecksumMismatch is matched by a ChecksumError
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrUnexpectedContentType is returned if a document is served with a content type other than the announced one
+	ErrUnexpectedContentType = errors.New("unexpected content type")
+)
+
+// ChecksumError is returned if the content of a downloaded document does not match its checksum
+type ChecksumError struct {
+	DocumentID string
+	Expected   string
+	Actual     string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch: expected %s, got %s", e.Expected, e.Actual)
+}
+
+func (e *ChecksumError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
+// GetDocument returns the metadata of the document identified by id
+func (c *Client) GetDocument(ctx context.Context, id string) (Document, error) {
+	u, err := c.endpointURL(endpointDocuments, nil, id)
+	if err != nil {
+		return Document{}, err
+	}
+
+	// The content is served from the same URL, so the metadata has to be requested explicitly
+	req, err := c.newRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return Document{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.api+json")
+	resp, err := c.do(req)
+	if err != nil {
+		return Document{}, err
+	}
+	defer resp.Body.Close()
+
+	var r struct {
+		Data Document `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&r)
+	if err != nil {
+		return Document{}, fmt.Errorf("decoding response of %s: %w", u, err)
+	}
+
+	return r.Data, nil
+}
+
+// DownloadDocument streams the content of the document identified by id to w. See DownloadDocumentContent.
+func (c *Client) DownloadDocument(ctx context.Context, id string, w io.Writer) error {
+	d, err := c.GetDocument(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.DownloadDocumentContent(ctx, d, w)
+}
+
+// DownloadDocumentContent streams the content of d to w and verifies it against the checksum of d, returning a
+// *ChecksumError if it does not match. Since the content is streamed, w has received the content by then; callers
+// writing to a file should write to a temporary file first. An error wrapping ErrUnexpectedContentType is returned
+// if the content type of the response differs from the one of d.
+func (c *Client) DownloadDocumentContent(ctx context.Context, d Document, w io.Writer) error {
+	u, err := c.endpointURL(endpointDocuments, nil, d.ID)
+	if err != nil {
+		return err
+	}
+
+	contentType := d.Attributes.ContentType
+	if contentType == "" {
+		contentType = defaultDocumentContentType
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", contentType)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get("Content-Type"); got != "" {
+		mt, _, err := mime.ParseMediaType(got)
+		if err != nil || !strings.EqualFold(mt, contentType) {
+			return fmt.Errorf("%w %q, expected %q", ErrUnexpectedContentType, got, contentType)
+		}
+	}
+
+	h, expected := checksumHash(d.Attributes.Checksum)
+	if h == nil {
+		c.logger.Printf("document %s: not verifying unsupported checksum %q", d.ID, d.Attributes.Checksum)
+		_, err = io.Copy(w, resp.Body)
+		return err
+	}
+
+	_, err = io.Copy(io.MultiWriter(w, h), resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if actual := h.Sum(nil); !bytes.Equal(actual, expected) {
+		return &ChecksumError{DocumentID: d.ID, Expected: d.Attributes.Checksum, Actual: hex.EncodeToString(actual)}
+	}
+	return nil
+}
+
+// checksumHash returns the hash matching the length of the hex or base64 encoded checksum and the decoded checksum.
+// The returned hash is nil if the checksum is empty or its encoding or algorithm cannot be determined.
+func checksumHash(checksum string) (hash.Hash, []byte) {
+	if checksum == "" {
+		return nil, nil
+	}
+
+	sum, err := hex.DecodeString(checksum)
+	if err != nil {
+		sum, err = base64.StdEncoding.DecodeString(checksum)
+		if err != nil {
+			return nil, nil
+		}
+	}
+
+	switch len(sum) {
+	case md5.Size:
+		return md5.New(), sum
+	case sha1.Size:
+		return sha1.New(), sum
+	case sha256.Size:
+		return sha256.New(), sum
+	case sha512.Size:
+		return sha512.New(), sum
+	}
+	return nil, nil
+}
@@ -0,0 +1,110 @@
+package dkbclient
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// DocumentWriter receives the content of a document downloaded by DownloadDocuments
+type DocumentWriter interface {
+	io.Writer
+	// Commit is called once the content has been written completely and verified
+	Commit() error
+	// Abort is called instead of Commit if the download failed
+	Abort() error
+}
+
+// DownloadOptions configures DownloadDocuments
+type DownloadOptions struct {
+	// Workers is the number of documents downloaded concurrently; values below 1 are treated as 1
+	Workers int
+	// Interval is the minimum time between the start of two downloads; zero disables rate limiting
+	Interval time.Duration
+}
+
+// DefaultDownloadOptions downloads up to four documents concurrently, starting at most four downloads per second
+var DefaultDownloadOptions = DownloadOptions{Workers: 4, Interval: 250 * time.Millisecond}
+
+// DownloadDocuments downloads docs concurrently as configured by opts. For each document, open is called to obtain
+// the DocumentWriter the content is streamed to, which is then committed or aborted. Documents failing to download
+// do not stop the others; their errors are returned as DocumentErrors, in the order of docs. If ctx is done, the
+// remaining documents are skipped and reported with ctx.Err().
+func (c *Client) DownloadDocuments(ctx context.Context, docs []Document, open func(Document) (DocumentWriter, error), opts DownloadOptions) error {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan int)
+	errs := make([]error, len(docs))
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				errs[j] = c.downloadTo(ctx, docs[j], open)
+			}
+		}()
+	}
+
+	// The interval is timed from the moment a worker has accepted the previous job, so that waiting for an idle worker
+	// does not let two downloads start back-to-back
+	var next *time.Timer
+	dispatched := 0
+dispatch:
+	for i := range docs {
+		if next != nil {
+			select {
+			case <-ctx.Done():
+				next.Stop()
+				break dispatch
+			case <-next.C:
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- i:
+			dispatched++
+		}
+		if opts.Interval > 0 {
+			next = time.NewTimer(opts.Interval)
+		}
+	}
+	if next != nil {
+		next.Stop()
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i := dispatched; i < len(docs); i++ {
+		errs[i] = ctx.Err()
+	}
+
+	var de DocumentErrors
+	for i, err := range errs {
+		if err != nil {
+			de = append(de, &DocumentError{DocumentID: docs[i].ID, Err: err})
+		}
+	}
+	return de.err()
+}
+
+// downloadTo downloads d to the DocumentWriter returned by open
+func (c *Client) downloadTo(ctx context.Context, d Document, open func(Document) (DocumentWriter, error)) error {
+	w, err := open(d)
+	if err != nil {
+		return err
+	}
+
+	err = c.DownloadDocumentContent(ctx, d, w)
+	if err != nil {
+		w.Abort()
+		return err
+	}
+	return w.Commit()
+}
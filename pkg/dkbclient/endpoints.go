@@ -0,0 +1,52 @@
+package dkbclient
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultBaseURL is the base URL of the DKB web interface used when Client.BaseURL is empty
+const DefaultBaseURL = "https://banking.dkb.de"
+
+// endpoint is a path of the DKB web interface, relative to the client's base URL
+type endpoint string
+
+const (
+	endpointLogin         endpoint = "/login"
+	endpointToken         endpoint = "/api/token"
+	endpointRevoke        endpoint = "/api/revoke"
+	endpointMFAMethods    endpoint = "/api/mfa/mfa/methods"
+	endpointMFAChallenges endpoint = "/api/mfa/mfa/challenges"
+	endpointAccounts      endpoint = "/api/accounts/accounts"
+	endpointCreditCards   endpoint = "/api/credit-card/cards"
+	endpointDocuments     endpoint = "/api/documentstorage/documents"
+	endpointMessages      endpoint = "/api/documentstorage/messages"
+	endpointDocumentTypes endpoint = "/api/documentstorage/documentTypes"
+)
+
+// baseURL returns the parsed base URL of c, falling back to DefaultBaseURL
+func (c *Client) baseURL() (*url.URL, error) {
+	b := c.BaseURL
+	if b == "" {
+		b = DefaultBaseURL
+	}
+	u, err := url.Parse(strings.TrimSuffix(b, "/"))
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// endpointURL resolves e against the base URL of c. Additional path segments are appended to the endpoint's
+// path, query is encoded as the URL's query string.
+func (c *Client) endpointURL(e endpoint, query url.Values, segments ...string) (string, error) {
+	u, err := c.baseURL()
+	if err != nil {
+		return "", err
+	}
+
+	u = u.JoinPath(append([]string{string(e)}, segments...)...)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
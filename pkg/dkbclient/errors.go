@@ -0,0 +1,139 @@
+package dkbclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUnauthorized is matched by an APIError with status 401 Unauthorized
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrSessionExpired is matched by an APIError with status 401 Unauthorized returned after a successful login
+	ErrSessionExpired = errors.New("session expired")
+	// ErrNotFound is matched by an APIError with status 404 Not Found
+	ErrNotFound = errors.New("not found")
+	// ErrRateLimited is matched by an APIError with status 429 Too Many Requests
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// maxErrorBodySize limits how much of an error response body is read
+const maxErrorBodySize = 1 << 20
+
+// APIError is returned when the DKB API responds with a status code other than 2xx
+type APIError struct {
+	StatusCode int
+	Method     string
+	URL        string
+	// Errors holds the JSON:API error objects of the response, if any
+	Errors []ErrorObject
+	// RetryAfter is the delay requested by the Retry-After header, if present
+	RetryAfter time.Duration
+
+	authenticated bool
+}
+
+// ErrorObject is a JSON:API error object
+type ErrorObject struct {
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status,omitempty"`
+	Code   string `json:"code,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+	for _, o := range e.Errors {
+		var parts []string
+		for _, p := range []string{o.Code, o.Title, o.Detail} {
+			if p != "" {
+				parts = append(parts, p)
+			}
+		}
+		if len(parts) > 0 {
+			msg += "; " + strings.Join(parts, ": ")
+		}
+	}
+	return msg
+}
+
+// Is reports whether e matches one of the sentinel errors of this package
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrSessionExpired:
+		return e.StatusCode == http.StatusUnauthorized && e.authenticated
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// newAPIError builds an APIError from resp, consuming and closing its body
+func newAPIError(resp *http.Response, authenticated bool) *APIError {
+	defer resp.Body.Close()
+
+	e := &APIError{StatusCode: resp.StatusCode, authenticated: authenticated}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = resp.Request.URL.Redacted()
+	}
+	if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		e.RetryAfter = time.Duration(s) * time.Second
+	} else if t, err := http.ParseTime(resp.Header.Get("Retry-After")); err == nil {
+		e.RetryAfter = time.Until(t)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		return e
+	}
+	var body struct {
+		Errors []ErrorObject `json:"errors"`
+	}
+	if json.Unmarshal(b, &body) == nil {
+		e.Errors = body.Errors
+	}
+	return e
+}
+
+// DocumentError is the error of an operation on a single document
+type DocumentError struct {
+	DocumentID string
+	Err        error
+}
+
+func (e *DocumentError) Error() string {
+	return fmt.Sprintf("document %s: %v", e.DocumentID, e.Err)
+}
+
+func (e *DocumentError) Unwrap() error {
+	return e.Err
+}
+
+// DocumentErrors collects the errors of an operation on multiple documents
+type DocumentErrors []*DocumentError
+
+func (e DocumentErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%d documents failed, first error: %v", len(e), e[0])
+}
+
+// err returns e as an error, or nil if e is empty
+func (e DocumentErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
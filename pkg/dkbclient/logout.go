@@ -0,0 +1,120 @@
+package dkbclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logout is like LogoutContext, using context.Background()
+func (c *Client) Logout() error {
+	return c.LogoutContext(context.Background())
+}
+
+// LogoutContext revokes the tokens of c server-side and clears its cookies and tokens. The local session state is
+// cleared even if revoking fails. A keep-alive started by StartKeepAlive must be stopped before.
+func (c *Client) LogoutContext(ctx context.Context) error {
+	if !c.authenticated {
+		return ErrNotAuthenticated
+	}
+
+	err := c.revokeToken(ctx)
+
+	c.tokens.mu.Lock()
+	c.accessToken = ""
+	c.mfaId = ""
+	c.tokens.accessExpiresAt = time.Time{}
+	c.tokens.refreshToken = ""
+	c.tokens.refreshExpiresAt = time.Time{}
+	c.tokens.mu.Unlock()
+	c.xsrfToken = ""
+	c.authenticated = false
+
+	jar, jerr := cookiejar.New(nil)
+	if jerr != nil && err == nil {
+		return jerr
+	}
+	if jar != nil {
+		c.httpClient.Jar = jar
+	}
+
+	return err
+}
+
+// revokeToken revokes the refresh token of c, or its access token if there is no refresh token
+func (c *Client) revokeToken(ctx context.Context) error {
+	c.tokens.mu.Lock()
+	token, hint := c.tokens.refreshToken, "refresh_token"
+	if token == "" {
+		token, hint = c.accessToken, "access_token"
+	}
+	c.tokens.mu.Unlock()
+
+	data := url.Values{}
+	data.Add("token", token)
+	data.Add("token_type_hint", hint)
+
+	u, err := c.endpointURL(endpointRevoke, nil)
+	if err != nil {
+		return err
+	}
+	r, err := c.newRequest(ctx, http.MethodPost, u, strings.NewReader(data.Encode()))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.send(r)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// StartKeepAlive keeps the session of c alive by sending a request every interval, refreshing the access token when
+// needed. The keep-alive ends once the session cannot be renewed, ctx is done or the returned stop function is
+// called; stop waits for a request in progress to finish.
+func (c *Client) StartKeepAlive(ctx context.Context, interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+
+			_, err := c.GetAccountsContext(ctx)
+			if err == nil {
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.Printf("keep-alive failed: %v", err)
+			if errors.Is(err, ErrReauthenticationRequired) || errors.Is(err, ErrSessionExpired) {
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package dkbclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// MarkDocumentRead marks the document identified by id as read in the mailbox
+func (c *Client) MarkDocumentRead(ctx context.Context, id string) error {
+	return c.patchMessage(ctx, id, map[string]any{"read": true})
+}
+
+// MarkDocumentsRead marks the documents identified by ids as read in the mailbox. All documents are attempted; the
+// failed ones are returned as DocumentErrors.
+func (c *Client) MarkDocumentsRead(ctx context.Context, ids []string) error {
+	var errs DocumentErrors
+	for _, id := range ids {
+		if err := c.MarkDocumentRead(ctx, id); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errs = append(errs, &DocumentError{DocumentID: id, Err: err})
+		}
+	}
+	return errs.err()
+}
+
+// ArchiveDocument moves the document identified by id to the archive of the mailbox
+func (c *Client) ArchiveDocument(ctx context.Context, id string) error {
+	return c.patchMessage(ctx, id, map[string]any{"archived": true})
+}
+
+// patchMessage updates the attributes of the mailbox entry of the document identified by id
+func (c *Client) patchMessage(ctx context.Context, id string, attributes map[string]any) error {
+	body := map[string]any{
+		"data": map[string]any{
+			"type":       "message",
+			"attributes": attributes,
+		},
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	u, err := c.endpointURL(endpointMessages, nil, id)
+	if err != nil {
+		return err
+	}
+	r, err := c.newRequest(ctx, http.MethodPatch, u, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/vnd.api+json")
+	r.Header.Set("Accept", "application/vnd.api+json")
+
+	resp, err := c.do(r)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
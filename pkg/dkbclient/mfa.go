@@ -0,0 +1,146 @@
+package dkbclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// MFAMethodTypeSealOne is the method type of the DKB app's push approval
+const MFAMethodTypeSealOne = "seal_one"
+
+// VerificationStatus is the state of an MFA challenge
+type VerificationStatus string
+
+const (
+	// VerificationStatusPending indicates the challenge has not been answered yet
+	VerificationStatusPending VerificationStatus = "pending"
+	// VerificationStatusProcessed indicates the challenge has been approved
+	VerificationStatusProcessed VerificationStatus = "processed"
+	// VerificationStatusCanceled indicates the challenge has been canceled
+	VerificationStatusCanceled VerificationStatus = "canceled"
+	// VerificationStatusExpired indicates the challenge has not been answered in time
+	VerificationStatusExpired VerificationStatus = "expired"
+	// VerificationStatusRejected indicates the challenge has been rejected on the MFA device
+	VerificationStatusRejected VerificationStatus = "rejected"
+	// VerificationStatusAuthorized indicates a TAN sent in response to the challenge has been accepted
+	VerificationStatusAuthorized VerificationStatus = "authorized"
+)
+
+var (
+	// ErrMFATimeout is returned by Login if the MFA challenge has not been approved within the configured poll retries
+	ErrMFATimeout = errors.New("MFA challenge was not approved in time")
+	// ErrMFARejected is returned by Login if the MFA challenge has been rejected
+	ErrMFARejected = errors.New("MFA challenge was rejected")
+	// ErrMFACanceled is returned by Login if the MFA challenge has been canceled
+	ErrMFACanceled = errors.New("MFA challenge was canceled")
+	// ErrMFAExpired is returned by Login if the MFA challenge has expired server-side
+	ErrMFAExpired = errors.New("MFA challenge expired")
+	// ErrMFAUnknownStatus is returned by Login if the MFA challenge is in a status it does not know
+	ErrMFAUnknownStatus = errors.New("unknown MFA verification status")
+	// ErrTANProviderRequired is returned by Login if the selected MFA method requires a TAN, but no TANProvider is set
+	ErrTANProviderRequired = errors.New("MFA method requires a TAN, but no TANProvider is set")
+)
+
+// TANProvider asks the user for the TAN answering challenge, which has been created for method
+type TANProvider func(ctx context.Context, method MFAMethod, challenge MFAChallengeResponseData) (string, error)
+
+// RequiresTAN reports whether m is a challenge-response method, i.e. the user has to enter a TAN instead of approving
+// the login on their device. All methods except seal_one are considered challenge-response methods.
+func (m MFAMethod) RequiresTAN() bool {
+	return m.Attributes.MethodType != MFAMethodTypeSealOne
+}
+
+// Err returns the error corresponding to a final, unsuccessful status, or nil otherwise
+func (s VerificationStatus) Err() error {
+	switch s {
+	case VerificationStatusRejected:
+		return ErrMFARejected
+	case VerificationStatusCanceled:
+		return ErrMFACanceled
+	case VerificationStatusExpired:
+		return ErrMFAExpired
+	}
+	return nil
+}
+
+// MFAObserver is notified about the progress of the MFA step of Login
+type MFAObserver interface {
+	// OnMethodSelected is called with the MFA method returned by the MfaMethodSelector
+	OnMethodSelected(method MFAMethod)
+	// OnChallengeCreated is called once the challenge has been sent to the selected method's device
+	OnChallengeCreated(challenge MFAChallengeResponseData)
+	// OnPoll is called with the result of each verification status poll; attempt starts at 1
+	OnPoll(status VerificationStatus, attempt int)
+	// OnApproved is called once the challenge has been approved
+	OnApproved()
+}
+
+// NopMFAObserver is an MFAObserver that does nothing. It can be embedded to implement only some of the methods.
+type NopMFAObserver struct{}
+
+func (NopMFAObserver) OnMethodSelected(MFAMethod)                  {}
+func (NopMFAObserver) OnChallengeCreated(MFAChallengeResponseData) {}
+func (NopMFAObserver) OnPoll(VerificationStatus, int)              {}
+func (NopMFAObserver) OnApproved()                                 {}
+
+// answerMFAChallenge asks the TANProvider of c for a TAN and sends it in response to challenge. If the challenge is
+// still pending afterwards, its status is polled until it is final.
+func (c *Client) answerMFAChallenge(ctx context.Context, method MFAMethod, challenge MFAChallengeResponseData) error {
+	tan, err := c.tanProvider(ctx, method, challenge)
+	if err != nil {
+		return err
+	}
+
+	ch := MFAChallenge{Data: MFAChallengeData{Type: "mfa-challenge",
+		Attributes: MFAChallengeDataAttributes{
+			MethodType:        method.Attributes.MethodType,
+			ChallengeResponse: tan,
+		}}}
+	chb, err := json.Marshal(ch)
+	if err != nil {
+		return err
+	}
+
+	u, err := c.endpointURL(endpointMFAChallenges, nil, challenge.ID)
+	if err != nil {
+		return err
+	}
+	r, err := c.newRequest(ctx, http.MethodPost, u, bytes.NewReader(chb))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/vnd.api+json")
+	resp, err := c.do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	cr := MFAChallengeResponse{}
+	err = json.Unmarshal(b, &cr)
+	if err != nil {
+		return err
+	}
+
+	status := cr.Data.Attributes.VerificationStatus
+	c.mfaObserver.OnPoll(status, 1)
+	switch status {
+	case VerificationStatusAuthorized, VerificationStatusProcessed:
+		return nil
+	case VerificationStatusPending:
+		return c.pollVerificationStatus(ctx, challenge.ID)
+	}
+	if err := status.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w %q", ErrMFAUnknownStatus, status)
+}
@@ -0,0 +1,325 @@
+package dkbclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrCurrencyMismatch is returned when combining amounts of different currencies
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// Money is an exact decimal amount of a currency, as returned by the API in the form
+// {"currencyCode": "EUR", "value": "12.34"}. The zero value is an amount without value and currency, which counts as
+// zero. Money values are immutable; use Cmp instead of == to compare them.
+type Money struct {
+	// Currency is the ISO 4217 currency code
+	Currency string
+	// unscaled is the amount in 10^-scale units of the currency, nil if the amount has no value
+	unscaled *big.Int
+	scale    int
+}
+
+// ParseMoney parses value, a decimal number with a "." as decimal separator, as amount of currency
+func ParseMoney(value, currency string) (Money, error) {
+	m := Money{Currency: currency}
+	s := strings.TrimSpace(value)
+	sign := ""
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = "-", s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	integer, fraction, _ := strings.Cut(s, ".")
+	if integer+fraction == "" || strings.Trim(integer+fraction, "0123456789") != "" {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+	m.unscaled, _ = new(big.Int).SetString(sign+integer+fraction, 10)
+	m.scale = len(fraction)
+	return m, nil
+}
+
+// int returns the unscaled amount of m at the given scale, which must not be less than the one of m
+func (m Money) int(scale int) *big.Int {
+	i := new(big.Int)
+	if m.unscaled == nil {
+		return i
+	}
+	exp := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale-m.scale)), nil)
+	return i.Mul(m.unscaled, exp)
+}
+
+// align returns the currency and scale two amounts are combined in
+func (m Money) align(o Money) (string, int, error) {
+	currency := m.Currency
+	switch {
+	case o.Currency == "":
+	case currency == "":
+		currency = o.Currency
+	case currency != o.Currency:
+		return "", 0, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	scale := m.scale
+	if o.scale > scale {
+		scale = o.scale
+	}
+	return currency, scale, nil
+}
+
+// Add returns m + o. Amounts without currency can be combined with any currency.
+func (m Money) Add(o Money) (Money, error) {
+	currency, scale, err := m.align(o)
+	if err != nil {
+		return Money{}, err
+	}
+	sum := m.int(scale)
+	return Money{Currency: currency, unscaled: sum.Add(sum, o.int(scale)), scale: scale}, nil
+}
+
+// Sub returns m - o. Amounts without currency can be combined with any currency.
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(o.Neg())
+}
+
+// Sum returns the sum of the amounts, which must be of the same currency
+func Sum(amounts ...Money) (Money, error) {
+	var sum Money
+	for _, a := range amounts {
+		var err error
+		sum, err = sum.Add(a)
+		if err != nil {
+			return Money{}, err
+		}
+	}
+	return sum, nil
+}
+
+// Neg returns -m
+func (m Money) Neg() Money {
+	if m.unscaled != nil {
+		m.unscaled = new(big.Int).Neg(m.unscaled)
+	}
+	return m
+}
+
+// Abs returns m without its sign
+func (m Money) Abs() Money {
+	if m.Sign() < 0 {
+		return m.Neg()
+	}
+	return m
+}
+
+// Sign returns -1, 0 or 1 depending on whether m is negative, zero or positive
+func (m Money) Sign() int {
+	if m.unscaled == nil {
+		return 0
+	}
+	return m.unscaled.Sign()
+}
+
+// IsZero reports whether m is zero
+func (m Money) IsZero() bool {
+	return m.Sign() == 0
+}
+
+// Cmp compares m and o, returning -1, 0 or 1 if m is less than, equal to or greater than o
+func (m Money) Cmp(o Money) (int, error) {
+	d, err := m.Sub(o)
+	if err != nil {
+		return 0, err
+	}
+	return d.Sign(), nil
+}
+
+// Rat returns m as rational number
+func (m Money) Rat() *big.Rat {
+	denom := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(m.scale)), nil)
+	return new(big.Rat).SetFrac(m.int(m.scale), denom)
+}
+
+// Value returns the amount of m as decimal number with a "." as decimal separator and at least two fractional digits
+func (m Money) Value() string {
+	return m.Format(".", "")
+}
+
+// Format formats the amount of m with at least two fractional digits, using the given separators; thousandsSep may
+// be empty
+func (m Money) Format(decimalSep, thousandsSep string) string {
+	scale := m.scale
+	if scale < 2 {
+		scale = 2
+	}
+	digits := m.int(scale)
+	negative := digits.Sign() < 0
+	s := digits.Abs(digits).String()
+	if len(s) <= scale {
+		s = strings.Repeat("0", scale-len(s)+1) + s
+	}
+	integer, fraction := s[:len(s)-scale], s[len(s)-scale:]
+
+	if thousandsSep != "" {
+		var groups []string
+		for len(integer) > 3 {
+			groups = append([]string{integer[len(integer)-3:]}, groups...)
+			integer = integer[:len(integer)-3]
+		}
+		integer = strings.Join(append([]string{integer}, groups...), thousandsSep)
+	}
+
+	s = integer + decimalSep + fraction
+	if negative {
+		s = "-" + s
+	}
+	return s
+}
+
+// String returns the amount followed by the currency, e.g. "-12.34 EUR"
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Value()
+	}
+	return m.Value() + " " + m.Currency
+}
+
+// moneyJSON is the representation of Money in the API
+type moneyJSON struct {
+	CurrencyCode string `json:"currencyCode,omitempty"`
+	Value        string `json:"value,omitempty"`
+}
+
+func (m Money) json() moneyJSON {
+	j := moneyJSON{CurrencyCode: m.Currency}
+	if m.unscaled != nil {
+		j.Value = m.Value()
+	}
+	return j
+}
+
+// MarshalJSON encodes m in the form used by the API
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.json())
+}
+
+// UnmarshalJSON decodes m from the form used by the API, accepting the value as string or number
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var j struct {
+		CurrencyCode string          `json:"currencyCode"`
+		Value        json.RawMessage `json:"value"`
+	}
+	err := json.Unmarshal(data, &j)
+	if err != nil {
+		return err
+	}
+	*m, err = parseMoneyJSON(j.Value, j.CurrencyCode)
+	return err
+}
+
+// parseMoneyJSON parses value, a JSON string or number, as amount of currency. An empty or null value results in an
+// amount without value.
+func parseMoneyJSON(value json.RawMessage, currency string) (Money, error) {
+	s := string(value)
+	if strings.HasPrefix(s, `"`) {
+		var err error
+		s, err = strconv.Unquote(s)
+		if err != nil {
+			return Money{}, err
+		}
+	}
+	if s == "" || s == "null" {
+		return Money{Currency: currency}, nil
+	}
+	return ParseMoney(s, currency)
+}
+
+// ConvertedAmount is an amount converted from a foreign currency at ConversionRate, if any
+type ConvertedAmount struct {
+	Money
+	ConversionRate string
+}
+
+// MarshalJSON encodes a in the form used by the API
+func (a ConvertedAmount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		moneyJSON
+		ConversionRate string `json:"conversionRate,omitempty"`
+	}{a.json(), a.ConversionRate})
+}
+
+// UnmarshalJSON decodes a from the form used by the API
+func (a *ConvertedAmount) UnmarshalJSON(data []byte) error {
+	var j struct {
+		ConversionRate string `json:"conversionRate"`
+	}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	a.ConversionRate = j.ConversionRate
+	return a.Money.UnmarshalJSON(data)
+}
+
+// Balance is the balance of a card at Date
+type Balance struct {
+	Money
+	Date string
+}
+
+// MarshalJSON encodes b in the form used by the API
+func (b Balance) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		moneyJSON
+		Date string `json:"date,omitempty"`
+	}{b.json(), b.Date})
+}
+
+// UnmarshalJSON decodes b from the form used by the API
+func (b *Balance) UnmarshalJSON(data []byte) error {
+	var j struct {
+		Date string `json:"date"`
+	}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	b.Date = j.Date
+	return b.Money.UnmarshalJSON(data)
+}
+
+// Limit is the credit limit of a card, possibly split into categories
+type Limit struct {
+	Money
+	Identifier string
+	Categories []LimitCategory
+}
+
+// LimitCategory is the part of a Limit reserved for a category
+type LimitCategory struct {
+	Name   string `json:"name"`
+	Amount Money  `json:"amount"`
+}
+
+// MarshalJSON encodes l in the form used by the API
+func (l Limit) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		moneyJSON
+		Identifier string          `json:"identifier,omitempty"`
+		Categories []LimitCategory `json:"categories,omitempty"`
+	}{l.json(), l.Identifier, l.Categories})
+}
+
+// UnmarshalJSON decodes l from the form used by the API
+func (l *Limit) UnmarshalJSON(data []byte) error {
+	var j struct {
+		Identifier string          `json:"identifier"`
+		Categories []LimitCategory `json:"categories"`
+	}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	l.Identifier, l.Categories = j.Identifier, j.Categories
+	return l.Money.UnmarshalJSON(data)
+}
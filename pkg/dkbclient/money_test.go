@@ -0,0 +1,228 @@
+package dkbclient
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func mustParseMoney(t *testing.T, value, currency string) Money {
+	t.Helper()
+	m, err := ParseMoney(value, currency)
+	if err != nil {
+		t.Fatalf("ParseMoney(%q, %q): %v", value, currency, err)
+	}
+	return m
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "12.34", want: "12.34"},
+		{value: "12", want: "12.00"},
+		{value: "+1.5", want: "1.50"},
+		{value: "-.5", want: "-0.50"},
+		{value: " 0.001 ", want: "0.001"},
+		{value: "-0", want: "0.00"},
+		{value: "", wantErr: true},
+		{value: "-", wantErr: true},
+		{value: ".", wantErr: true},
+		{value: "1.2.3", wantErr: true},
+		{value: "1,23", wantErr: true},
+		{value: "1e3", wantErr: true},
+	}
+	for _, tt := range tests {
+		m, err := ParseMoney(tt.value, "EUR")
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q) = %v, want error", tt.value, m)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q): %v", tt.value, err)
+			continue
+		}
+		if got := m.Value(); got != tt.want {
+			t.Errorf("ParseMoney(%q).Value() = %q, want %q", tt.value, got, tt.want)
+		}
+		if m.Currency != "EUR" {
+			t.Errorf("ParseMoney(%q).Currency = %q, want EUR", tt.value, m.Currency)
+		}
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	tests := []struct {
+		value        string
+		decimalSep   string
+		thousandsSep string
+		want         string
+	}{
+		{"1234.5", ".", "", "1234.50"},
+		{"1234.5", ",", ".", "1.234,50"},
+		{"-1234.5", ",", ".", "-1.234,50"},
+		{"12345678.123", ",", ".", "12.345.678,123"},
+		{"-123456", ".", ",", "-123,456.00"},
+		{"123", ",", ".", "123,00"},
+		{"-0.05", ",", ".", "-0,05"},
+		{"0.5", ".", "", "0.50"},
+		{"-7", ".", "", "-7.00"},
+	}
+	for _, tt := range tests {
+		m := mustParseMoney(t, tt.value, "EUR")
+		if got := m.Format(tt.decimalSep, tt.thousandsSep); got != tt.want {
+			t.Errorf("Format(%q, %q) of %s = %q, want %q", tt.decimalSep, tt.thousandsSep, tt.value, got, tt.want)
+		}
+	}
+
+	if got := (Money{}).Value(); got != "0.00" {
+		t.Errorf("Money{}.Value() = %q, want 0.00", got)
+	}
+}
+
+func TestMoneyAdd(t *testing.T) {
+	tests := []struct {
+		a, b    Money
+		want    string
+		wantErr bool
+	}{
+		{a: mustParseMoney(t, "1.1", "EUR"), b: mustParseMoney(t, "2.005", "EUR"), want: "3.105 EUR"},
+		{a: mustParseMoney(t, "1", "EUR"), b: mustParseMoney(t, "-3.5", "EUR"), want: "-2.50 EUR"},
+		{a: Money{}, b: mustParseMoney(t, "1", "USD"), want: "1.00 USD"},
+		{a: mustParseMoney(t, "1", ""), b: mustParseMoney(t, "1", "EUR"), want: "2.00 EUR"},
+		{a: mustParseMoney(t, "1", "EUR"), b: mustParseMoney(t, "1", "USD"), wantErr: true},
+	}
+	for _, tt := range tests {
+		sum, err := tt.a.Add(tt.b)
+		if tt.wantErr {
+			if !errors.Is(err, ErrCurrencyMismatch) {
+				t.Errorf("%v + %v: error %v, want %v", tt.a, tt.b, err, ErrCurrencyMismatch)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v + %v: %v", tt.a, tt.b, err)
+			continue
+		}
+		if got := sum.String(); got != tt.want {
+			t.Errorf("%v + %v = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyCmp(t *testing.T) {
+	tests := []struct {
+		a, b    Money
+		want    int
+		wantErr bool
+	}{
+		{a: mustParseMoney(t, "1.10", "EUR"), b: mustParseMoney(t, "1.1", "EUR"), want: 0},
+		{a: mustParseMoney(t, "-1", "EUR"), b: mustParseMoney(t, "0.01", "EUR"), want: -1},
+		{a: mustParseMoney(t, "0.001", "EUR"), b: Money{}, want: 1},
+		{a: mustParseMoney(t, "1", "EUR"), b: mustParseMoney(t, "1", "USD"), wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.a.Cmp(tt.b)
+		if tt.wantErr {
+			if !errors.Is(err, ErrCurrencyMismatch) {
+				t.Errorf("Cmp(%v, %v): error %v, want %v", tt.a, tt.b, err, ErrCurrencyMismatch)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Cmp(%v, %v): %v", tt.a, tt.b, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Cmp(%v, %v) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`{"currencyCode":"EUR","value":"-12.5"}`, `{"currencyCode":"EUR","value":"-12.50"}`},
+		{`{"currencyCode":"EUR","value":1234.567}`, `{"currencyCode":"EUR","value":"1234.567"}`},
+		{`{"currencyCode":"EUR","value":null}`, `{"currencyCode":"EUR"}`},
+		{`{}`, `{}`},
+	}
+	for _, tt := range tests {
+		var m Money
+		if err := json.Unmarshal([]byte(tt.in), &m); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+			continue
+		}
+		b, err := json.Marshal(m)
+		if err != nil {
+			t.Errorf("Marshal(%v): %v", m, err)
+			continue
+		}
+		if string(b) != tt.want {
+			t.Errorf("round trip of %s = %s, want %s", tt.in, b, tt.want)
+		}
+	}
+
+	var m Money
+	if err := json.Unmarshal([]byte(`{"currencyCode":"EUR","value":"1.2.3"}`), &m); err == nil {
+		t.Errorf("Unmarshal of invalid value = %v, want error", m)
+	}
+}
+
+func TestMoneyCompositeJSON(t *testing.T) {
+	tests := []struct {
+		in string
+		v  any
+	}{
+		{`{"currencyCode":"USD","value":"10.00","conversionRate":"1.0856"}`, &ConvertedAmount{}},
+		{`{"currencyCode":"EUR","value":"-250.00","date":"2024-01-31"}`, &Balance{}},
+		{`{"currencyCode":"EUR","value":"2000.00","identifier":"main","categories":[{"name":"cash","amount":{"currencyCode":"EUR","value":"500.00"}}]}`, &Limit{}},
+		{`{"cardId":"c1","statementDate":"2024-01-31","statementAmount":"-12.30","subject":"Statement","statementID":"s1","statementCurrency":"EUR"}`, &DocumentMetadata{}},
+	}
+	for _, tt := range tests {
+		if err := json.Unmarshal([]byte(tt.in), tt.v); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+			continue
+		}
+		b, err := json.Marshal(tt.v)
+		if err != nil {
+			t.Errorf("Marshal(%+v): %v", tt.v, err)
+			continue
+		}
+		if string(b) != tt.in {
+			t.Errorf("round trip of %s = %s", tt.in, b)
+		}
+	}
+}
+
+func TestAccountAttributesOverdraftLimit(t *testing.T) {
+	var a AccountAttributes
+	err := json.Unmarshal([]byte(`{"currencyCode":"EUR","balance":{"currencyCode":"EUR","value":"1.00"},"overdraftLimit":"1500.00"}`), &a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.OverdraftLimit.String(); got != "1500.00 EUR" {
+		t.Errorf("OverdraftLimit = %q, want 1500.00 EUR", got)
+	}
+	if got := a.Balance.String(); got != "1.00 EUR" {
+		t.Errorf("Balance = %q, want 1.00 EUR", got)
+	}
+
+	b, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded AccountAttributes
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("Unmarshal(%s): %v", b, err)
+	}
+	if c, err := decoded.OverdraftLimit.Cmp(a.OverdraftLimit); err != nil || c != 0 {
+		t.Errorf("round trip of %s: OverdraftLimit = %v, want %v", b, decoded.OverdraftLimit, a.OverdraftLimit)
+	}
+}
@@ -0,0 +1,194 @@
+package dkbclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"time"
+)
+
+const (
+	defaultVerificationStatusPollInterval = 3000 * time.Millisecond
+	defaultVerificationStatusPollRetries  = 60
+)
+
+// Logger is the interface used by Client for diagnostic output. *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Printf(string, ...any) {}
+
+// Option configures a Client created by NewWithOptions
+type Option func(*options) error
+
+type options struct {
+	httpClient   *http.Client
+	transport    http.RoundTripper
+	baseURL      string
+	userAgent    string
+	logger       Logger
+	pollInterval time.Duration
+	pollRetries  int
+	timeout      time.Duration
+	mfaObserver  MFAObserver
+	tanProvider  TANProvider
+}
+
+// WithHTTPClient makes the Client use a copy of hc for all requests. A cookie jar is added if hc has none.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *options) error {
+		if hc == nil {
+			return errors.New("http client must not be nil")
+		}
+		o.httpClient = hc
+		return nil
+	}
+}
+
+// WithTransport sets the http.RoundTripper used for all requests
+func WithTransport(t http.RoundTripper) Option {
+	return func(o *options) error {
+		if t == nil {
+			return errors.New("transport must not be nil")
+		}
+		o.transport = t
+		return nil
+	}
+}
+
+// WithBaseURL sets the base URL all API endpoints are resolved against
+func WithBaseURL(u string) Option {
+	return func(o *options) error {
+		o.baseURL = u
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(ua string) Option {
+	return func(o *options) error {
+		o.userAgent = ua
+		return nil
+	}
+}
+
+// WithLogger sets the Logger used for diagnostic output. By default, nothing is logged.
+func WithLogger(l Logger) Option {
+	return func(o *options) error {
+		if l == nil {
+			l = nopLogger{}
+		}
+		o.logger = l
+		return nil
+	}
+}
+
+// WithVerificationStatusPolling sets how often and how many times the MFA verification status is polled during Login
+func WithVerificationStatusPolling(interval time.Duration, retries int) Option {
+	return func(o *options) error {
+		if interval < 0 || retries < 1 {
+			return errors.New("poll interval must not be negative and poll retries must be positive")
+		}
+		o.pollInterval = interval
+		o.pollRetries = retries
+		return nil
+	}
+}
+
+// WithTimeout sets a time limit for each request made by the Client
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) error {
+		if d < 0 {
+			return errors.New("timeout must not be negative")
+		}
+		o.timeout = d
+		return nil
+	}
+}
+
+// WithMFAObserver sets an MFAObserver that is notified about the progress of the MFA step of Login
+func WithMFAObserver(obs MFAObserver) Option {
+	return func(o *options) error {
+		if obs == nil {
+			obs = NopMFAObserver{}
+		}
+		o.mfaObserver = obs
+		return nil
+	}
+}
+
+// WithTANProvider sets the TANProvider used to answer challenges of MFA methods that require a TAN
+func WithTANProvider(p TANProvider) Option {
+	return func(o *options) error {
+		o.tanProvider = p
+		return nil
+	}
+}
+
+// NewWithOptions creates a new Client configured by opts
+func NewWithOptions(opts ...Option) (*Client, error) {
+	o := options{
+		baseURL:      DefaultBaseURL,
+		logger:       nopLogger{},
+		mfaObserver:  NopMFAObserver{},
+		pollInterval: defaultVerificationStatusPollInterval,
+		pollRetries:  defaultVerificationStatusPollRetries,
+	}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	httpClient := &http.Client{}
+	if o.httpClient != nil {
+		hc := *o.httpClient
+		httpClient = &hc
+	}
+	if httpClient.Jar == nil {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, err
+		}
+		httpClient.Jar = jar
+	}
+	if o.transport != nil {
+		httpClient.Transport = o.transport
+	}
+	if o.timeout > 0 {
+		httpClient.Timeout = o.timeout
+	}
+
+	c := &Client{
+		httpClient:                     httpClient,
+		tokens:                         &tokenState{},
+		documentTypes:                  &documentTypeCache{},
+		logger:                         o.logger,
+		mfaObserver:                    o.mfaObserver,
+		tanProvider:                    o.tanProvider,
+		userAgent:                      o.userAgent,
+		BaseURL:                        o.baseURL,
+		VerificationStatusPollInterval: o.pollInterval,
+		VerificationStatusPollRetries:  o.pollRetries,
+	}
+	if httpClient.CheckRedirect == nil {
+		httpClient.CheckRedirect = c.logRedirect
+	}
+
+	if _, err := c.baseURL(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Client) logRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	c.logger.Printf("redirect to %s", req.URL)
+	return nil
+}
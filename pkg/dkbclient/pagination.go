@@ -0,0 +1,145 @@
+package dkbclient
+
+import (
+	"context"
+	"net/url"
+)
+
+// Links holds the JSON:API pagination links of a collection response
+type Links struct {
+	Self  string `json:"self,omitempty"`
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+// page is a single page of a paginated JSON:API collection
+type page[T any] struct {
+	Data  []T   `json:"data"`
+	Links Links `json:"links"`
+}
+
+// Iterator streams the items of a paginated collection, fetching one page at a time. Call Next to advance to the
+// next item, which is then returned by Value. Once Next returns false, Err reports the error that ended the iteration,
+// if any.
+type Iterator[T any] struct {
+	ctx     context.Context
+	c       *Client
+	nextURL string
+	buf     []T
+	cur     T
+	err     error
+	// keep filters the items client-side, if set
+	keep func(T) bool
+	// prepare is applied to each item returned by Next, if set
+	prepare func(context.Context, *T) error
+	// remaining is the number of items still to be returned, if limit is set
+	remaining int
+	limit     bool
+}
+
+// AccountTransactionIterator streams the transactions of an account
+type AccountTransactionIterator = Iterator[AccountTransaction]
+
+// CreditCardTransactionIterator streams the transactions of a credit card
+type CreditCardTransactionIterator = Iterator[CreditCardTransaction]
+
+// DocumentIterator streams the documents of the document storage
+type DocumentIterator = Iterator[Document]
+
+func newIterator[T any](ctx context.Context, c *Client, firstURL string) *Iterator[T] {
+	return &Iterator[T]{ctx: ctx, c: c, nextURL: firstURL}
+}
+
+// newTransactionIterator returns an iterator over the transactions at firstURL matching q
+func newTransactionIterator[T interface{ matches(TransactionQuery) bool }](ctx context.Context, c *Client, firstURL string, q TransactionQuery) *Iterator[T] {
+	it := newIterator[T](ctx, c, firstURL)
+	it.keep = func(t T) bool { return t.matches(q) }
+	if q.Limit > 0 {
+		it.limit = true
+		it.remaining = q.Limit
+	}
+	return it
+}
+
+// Next advances the iterator to the next item, fetching the next page if required. It returns false once all items
+// have been returned or an error occurred.
+func (it *Iterator[T]) Next() bool {
+	if it.limit && it.remaining <= 0 {
+		return false
+	}
+
+	for {
+		for len(it.buf) == 0 {
+			if it.err != nil || it.nextURL == "" {
+				return false
+			}
+			it.fetch()
+		}
+
+		it.cur = it.buf[0]
+		it.buf = it.buf[1:]
+		if it.keep == nil || it.keep(it.cur) {
+			break
+		}
+	}
+
+	if it.prepare != nil {
+		if err := it.prepare(it.ctx, &it.cur); err != nil {
+			it.err = err
+			return false
+		}
+	}
+
+	it.remaining--
+	return true
+}
+
+// Value returns the current item
+func (it *Iterator[T]) Value() T {
+	return it.cur
+}
+
+// Err returns the error that ended the iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// fetch requests the page at it.nextURL and determines the URL of the following page
+func (it *Iterator[T]) fetch() {
+	var p page[T]
+	u := it.nextURL
+	it.err = get(it.ctx, it.c, u, &p)
+	if it.err != nil {
+		return
+	}
+
+	it.buf = p.Data
+	it.nextURL = ""
+	if p.Links.Next == "" {
+		return
+	}
+	cur, err := url.Parse(u)
+	if err != nil {
+		it.err = err
+		return
+	}
+	next, err := url.Parse(p.Links.Next)
+	if err != nil {
+		it.err = err
+		return
+	}
+	if n := cur.ResolveReference(next).String(); n != u {
+		it.nextURL = n
+	}
+}
+
+// collect returns all items of it
+func collect[T any](it *Iterator[T]) ([]T, error) {
+	var items []T
+	for it.Next() {
+		items = append(items, it.Value())
+	}
+	return items, it.Err()
+}
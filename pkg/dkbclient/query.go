@@ -0,0 +1,73 @@
+package dkbclient
+
+import (
+	"net/url"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// TransactionStatus is the booking status of a transaction
+type TransactionStatus string
+
+const (
+	TransactionStatusBooked  TransactionStatus = "booked"
+	TransactionStatusPending TransactionStatus = "pending"
+)
+
+// TransactionQuery restricts the transactions returned for an account or a card. The zero value matches all
+// transactions.
+type TransactionQuery struct {
+	// From and To restrict the booking date to the given range, both inclusive; only the date part is considered.
+	// Zero values leave the range open.
+	From time.Time
+	To   time.Time
+	// Status restricts the transactions to the given status, if not empty
+	Status TransactionStatus
+	// Limit is the maximum number of transactions returned, if positive
+	Limit int
+}
+
+// values returns the API query parameters of q supported by the account transactions endpoint
+func (q TransactionQuery) values() url.Values {
+	v := url.Values{}
+	if !q.From.IsZero() {
+		v.Set("filter[bookingDate][GE]", q.From.Format(dateLayout))
+	}
+	if !q.To.IsZero() {
+		v.Set("filter[bookingDate][LE]", q.To.Format(dateLayout))
+	}
+	return v
+}
+
+// matches reports whether a transaction with the given booking date (formatted as YYYY-MM-DD) and status matches q.
+// Transactions without a booking date do not match if q restricts the date range.
+func (q TransactionQuery) matches(bookingDate string, status string) bool {
+	if q.Status != "" && TransactionStatus(status) != q.Status {
+		return false
+	}
+	if q.From.IsZero() && q.To.IsZero() {
+		return true
+	}
+
+	d, err := time.Parse(dateLayout, bookingDate)
+	if err != nil {
+		return false
+	}
+	day := d.Format(dateLayout)
+	if !q.From.IsZero() && day < q.From.Format(dateLayout) {
+		return false
+	}
+	if !q.To.IsZero() && day > q.To.Format(dateLayout) {
+		return false
+	}
+	return true
+}
+
+func (t AccountTransaction) matches(q TransactionQuery) bool {
+	return q.matches(t.Attributes.BookingDate, t.Attributes.Status)
+}
+
+func (t CreditCardTransaction) matches(q TransactionQuery) bool {
+	return q.matches(t.Attributes.BookingDate, t.Attributes.Status)
+}
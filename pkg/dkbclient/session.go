@@ -0,0 +1,193 @@
+package dkbclient
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/scrypt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	sessionVersion = 2
+	// sessionSaltSize is the size of the random salt of the key derivation, stored after sessionMagic
+	sessionSaltSize = 16
+)
+
+// sessionMagic prefixes exported sessions, so that other data is rejected early by ImportSession
+var sessionMagic = []byte("dkbrobot-session\x02")
+
+var (
+	// ErrNotAuthenticated is returned by ExportSession if the Client is not logged in
+	ErrNotAuthenticated = errors.New("client is not logged in")
+	// ErrInvalidSession is returned by ImportSession if the data cannot be decrypted or parsed
+	ErrInvalidSession = errors.New("invalid session data or wrong key")
+)
+
+// session is the serialized state of an authenticated Client
+type session struct {
+	Version     int    `json:"version"`
+	BaseURL     string `json:"baseUrl"`
+	XSRFToken   string `json:"xsrfToken"`
+	MfaID       string `json:"mfaId"`
+	AccessToken string `json:"accessToken"`
+	// AccessTokenExpiresAt, RefreshToken and RefreshTokenExpiresAt are empty if the token response lacked them
+	AccessTokenExpiresAt  time.Time       `json:"accessTokenExpiresAt,omitempty"`
+	RefreshToken          string          `json:"refreshToken,omitempty"`
+	RefreshTokenExpiresAt time.Time       `json:"refreshTokenExpiresAt,omitempty"`
+	Cookies               []sessionCookie `json:"cookies"`
+	SavedAt               time.Time       `json:"savedAt"`
+}
+
+type sessionCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ExportSession serializes the cookies and tokens of the logged in Client c and encrypts them using AES-GCM with a
+// key derived from key using scrypt and a random salt, so that key may be a passphrase. The result can be passed to
+// ImportSession to reuse the session without logging in again.
+func (c *Client) ExportSession(key []byte) ([]byte, error) {
+	if !c.authenticated {
+		return nil, ErrNotAuthenticated
+	}
+
+	c.tokens.mu.Lock()
+	s := session{
+		Version:               sessionVersion,
+		BaseURL:               c.BaseURL,
+		XSRFToken:             c.xsrfToken,
+		MfaID:                 c.mfaId,
+		AccessToken:           c.accessToken,
+		AccessTokenExpiresAt:  c.tokens.accessExpiresAt,
+		RefreshToken:          c.tokens.refreshToken,
+		RefreshTokenExpiresAt: c.tokens.refreshExpiresAt,
+		SavedAt:               time.Now().UTC(),
+	}
+	c.tokens.mu.Unlock()
+
+	cookies, err := c.sessionCookies()
+	if err != nil {
+		return nil, err
+	}
+	for _, ck := range cookies {
+		s.Cookies = append(s.Cookies, sessionCookie{Name: ck.Name, Value: ck.Value})
+	}
+
+	plain, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, sessionSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	gcm, err := newSessionCipher(key, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	header := append(append([]byte{}, sessionMagic...), salt...)
+	out := append(append([]byte{}, header...), nonce...)
+	return gcm.Seal(out, nonce, plain, header), nil
+}
+
+// ImportSession restores a session exported by ExportSession, using the same key. The session must have been
+// exported by a Client with the same BaseURL. Whether the session is still valid is only known after the next request.
+func (c *Client) ImportSession(data []byte, key []byte) error {
+	if !bytes.HasPrefix(data, sessionMagic) || len(data) < len(sessionMagic)+sessionSaltSize {
+		return ErrInvalidSession
+	}
+	header := data[:len(sessionMagic)+sessionSaltSize]
+	data = data[len(header):]
+
+	gcm, err := newSessionCipher(key, header[len(sessionMagic):])
+	if err != nil {
+		return err
+	}
+	if len(data) < gcm.NonceSize() {
+		return ErrInvalidSession
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], header)
+	if err != nil {
+		return ErrInvalidSession
+	}
+
+	var s session
+	if err := json.Unmarshal(plain, &s); err != nil || s.Version != sessionVersion {
+		return ErrInvalidSession
+	}
+	if s.BaseURL != c.BaseURL {
+		return fmt.Errorf("session was exported for %s, but client uses %s", s.BaseURL, c.BaseURL)
+	}
+
+	u, err := c.baseURL()
+	if err != nil {
+		return err
+	}
+	cookies := make([]*http.Cookie, 0, len(s.Cookies))
+	for _, ck := range s.Cookies {
+		cookies = append(cookies, &http.Cookie{Name: ck.Name, Value: ck.Value, Path: "/", Secure: u.Scheme == "https"})
+	}
+	c.httpClient.Jar.SetCookies(u, cookies)
+
+	c.tokens.mu.Lock()
+	c.xsrfToken = s.XSRFToken
+	c.mfaId = s.MfaID
+	c.accessToken = s.AccessToken
+	c.tokens.accessExpiresAt = s.AccessTokenExpiresAt
+	c.tokens.refreshToken = s.RefreshToken
+	c.tokens.refreshExpiresAt = s.RefreshTokenExpiresAt
+	c.tokens.mu.Unlock()
+	c.authenticated = true
+
+	return nil
+}
+
+// sessionCookies returns the cookies of the jar of c sent to the web interface or to its API
+func (c *Client) sessionCookies() ([]*http.Cookie, error) {
+	b, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+
+	var cookies []*http.Cookie
+	seen := map[string]bool{}
+	for _, p := range []string{"/", "/api/"} {
+		for _, ck := range c.httpClient.Jar.Cookies(b.ResolveReference(&url.URL{Path: p})) {
+			if !seen[ck.Name] {
+				seen[ck.Name] = true
+				cookies = append(cookies, ck)
+			}
+		}
+	}
+	return cookies, nil
+}
+
+// newSessionCipher returns the AES-256-GCM cipher with the key derived from key and salt
+func newSessionCipher(key, salt []byte) (cipher.AEAD, error) {
+	if len(key) == 0 {
+		return nil, errors.New("session key must not be empty")
+	}
+	k, err := scrypt.Key(key, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
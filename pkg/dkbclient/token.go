@@ -0,0 +1,109 @@
+package dkbclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenRefreshMargin is how long before its expiry the access token is refreshed
+const tokenRefreshMargin = 30 * time.Second
+
+// ErrReauthenticationRequired is returned if the access token has expired and cannot be refreshed; Login has to be
+// called again
+var ErrReauthenticationRequired = errors.New("session cannot be renewed, login required")
+
+// tokenState tracks the expiry of the access token and the refresh token of a logged in Client
+type tokenState struct {
+	mu               sync.Mutex
+	accessExpiresAt  time.Time
+	refreshToken     string
+	refreshExpiresAt time.Time
+}
+
+// setTokenData updates the tokens of c from the response of the token endpoint
+func (c *Client) setTokenData(t TokenData) {
+	now := time.Now()
+
+	if t.AccessToken != "" {
+		c.accessToken = t.AccessToken
+	}
+	c.tokens.accessExpiresAt = time.Time{}
+	if t.ExpiresIn > 0 {
+		c.tokens.accessExpiresAt = now.Add(time.Duration(t.ExpiresIn) * time.Second)
+	}
+	c.tokens.refreshToken = t.RefreshToken
+	c.tokens.refreshExpiresAt = time.Time{}
+	if s, err := strconv.Atoi(t.RefreshTokenExpiresIn); err == nil && s > 0 {
+		c.tokens.refreshExpiresAt = now.Add(time.Duration(s) * time.Second)
+	}
+}
+
+// refreshIfNeeded refreshes the access token of c if it is about to expire. Nothing is done if the expiry of the
+// access token is unknown.
+func (c *Client) refreshIfNeeded(ctx context.Context) error {
+	c.tokens.mu.Lock()
+	defer c.tokens.mu.Unlock()
+
+	if c.tokens.accessExpiresAt.IsZero() || time.Until(c.tokens.accessExpiresAt) > tokenRefreshMargin {
+		return nil
+	}
+	return c.refresh(ctx)
+}
+
+// refresh obtains a new access token using the refresh token. c.tokens.mu must be held.
+func (c *Client) refresh(ctx context.Context) error {
+	if c.tokens.refreshToken == "" {
+		return ErrReauthenticationRequired
+	}
+	if !c.tokens.refreshExpiresAt.IsZero() && time.Now().After(c.tokens.refreshExpiresAt) {
+		return ErrReauthenticationRequired
+	}
+
+	data := url.Values{}
+	data.Add("grant_type", "refresh_token")
+	data.Add("refresh_token", c.tokens.refreshToken)
+
+	u, err := c.endpointURL(endpointToken, nil)
+	if err != nil {
+		return err
+	}
+	r, err := c.newRequest(ctx, http.MethodPost, u, strings.NewReader(data.Encode()))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.send(r)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusBadRequest || apiErr.StatusCode == http.StatusUnauthorized) {
+			return fmt.Errorf("%w: %v", ErrReauthenticationRequired, err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	buf := new(bytes.Buffer)
+	_, err = buf.ReadFrom(resp.Body)
+	if err != nil {
+		return err
+	}
+	tokenData := TokenData{}
+	err = json.Unmarshal(buf.Bytes(), &tokenData)
+	if err != nil {
+		return err
+	}
+	c.setTokenData(tokenData)
+	c.logger.Printf("refreshed access token, expires at %s", c.tokens.accessExpiresAt)
+
+	return nil
+}
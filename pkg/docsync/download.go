@@ -0,0 +1,47 @@
+package docsync
+
+import (
+	"context"
+	"errors"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"path/filepath"
+)
+
+// Download saves docs below dir at the paths given by tmpl, replacing existing files, and returns the paths of the
+// saved documents by ID. Documents yielding the same path get a numeric suffix. Failing documents are returned as
+// dkbclient.DocumentErrors, see dkbclient.Client.DownloadDocuments.
+func Download(ctx context.Context, c *dkbclient.Client, docs []dkbclient.Document, dir string, tmpl *PathTemplate, opts dkbclient.DownloadOptions) (map[string]string, error) {
+	allocator := &pathAllocator{dir: dir, taken: map[string]string{}, overwrite: true}
+
+	var errs dkbclient.DocumentErrors
+	var pending []dkbclient.Document
+	paths := map[string]string{}
+	for _, d := range docs {
+		rel, err := tmpl.Path(d)
+		if err != nil {
+			errs = append(errs, &dkbclient.DocumentError{DocumentID: d.ID, Err: err})
+			continue
+		}
+		paths[d.ID] = filepath.Join(dir, allocator.allocate(d.ID, rel))
+		pending = append(pending, d)
+	}
+
+	open := func(d dkbclient.Document) (dkbclient.DocumentWriter, error) {
+		return createFile(paths[d.ID])
+	}
+	err := c.DownloadDocuments(ctx, pending, open, opts)
+	var de dkbclient.DocumentErrors
+	if errors.As(err, &de) {
+		for _, e := range de {
+			delete(paths, e.DocumentID)
+		}
+		errs = append(errs, de...)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if len(errs) > 0 {
+		return paths, errs
+	}
+	return paths, nil
+}
@@ -0,0 +1,177 @@
+package docsync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// DefaultPathTemplate saves documents under their original file name
+const DefaultPathTemplate = "{{.FileName}}"
+
+// PathData is the data available to a path template
+type PathData struct {
+	ID       string
+	FileName string
+	// Year, Month and Day are taken from the creation date of the document
+	Year  string
+	Month string
+	Day   string
+	// Type is the display name of the document type, TypeID its ID and Category its category
+	Type          string
+	TypeID        string
+	Category      string
+	StatementDate string
+	StatementID   string
+	CardID        string
+	Subject       string
+	Owner         string
+}
+
+// PathTemplate builds the relative path of a document from a text/template, e.g.
+// "{{.Year}}/{{.Type}}/{{.StatementDate}}_{{.CardID}}_{{.Subject}}.pdf". All values are sanitized to be usable in a
+// file name; "/" in the template separates directories.
+type PathTemplate struct {
+	t *template.Template
+}
+
+// ParsePathTemplate parses a path template
+func ParsePathTemplate(text string) (*PathTemplate, error) {
+	t, err := template.New("path").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &PathTemplate{t: t}, nil
+}
+
+// newPathData returns the sanitized path data of d
+func newPathData(d dkbclient.Document) PathData {
+	created := d.Attributes.CreationDate
+	p := PathData{
+		ID:            d.ID,
+		FileName:      strings.TrimSuffix(d.Attributes.FileName, ".pdf"),
+		TypeID:        d.DocumentTypeID(),
+		StatementDate: d.Attributes.Metadata.StatementDate,
+		StatementID:   d.Attributes.Metadata.StatementID,
+		CardID:        d.Attributes.Metadata.CardID,
+		Subject:       d.Attributes.Metadata.Subject,
+		Owner:         d.Attributes.Owner,
+	}
+	if !created.IsZero() {
+		p.Year = created.Format("2006")
+		p.Month = created.Format("01")
+		p.Day = created.Format("02")
+	}
+	p.Type = p.TypeID
+	if d.DocumentType != nil {
+		p.Type = d.DocumentType.Label()
+		p.Category = d.DocumentType.Attributes.Category
+	}
+
+	for _, f := range []*string{&p.ID, &p.FileName, &p.Year, &p.Month, &p.Day, &p.Type, &p.TypeID, &p.Category,
+		&p.StatementDate, &p.StatementID, &p.CardID, &p.Subject, &p.Owner} {
+		*f = sanitize(*f)
+	}
+	return p
+}
+
+// Path returns the relative path of d, using the OS specific separator
+func (t *PathTemplate) Path(d dkbclient.Document) (string, error) {
+	buf := new(bytes.Buffer)
+	err := t.t.Execute(buf, newPathData(d))
+	if err != nil {
+		return "", err
+	}
+
+	var segments []string
+	for _, s := range strings.Split(buf.String(), "/") {
+		s = tidy(s)
+		if s == "" || s == "." || s == ".." {
+			continue
+		}
+		segments = append(segments, s)
+	}
+	if len(segments) == 0 {
+		return "", fmt.Errorf("path template yields an empty path for document %s", d.ID)
+	}
+
+	p := path.Join(segments...)
+	if !strings.HasSuffix(strings.ToLower(p), ".pdf") {
+		p += ".pdf"
+	}
+	return filepath.FromSlash(p), nil
+}
+
+// sanitize replaces characters that are not allowed or problematic in file names
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			return -1
+		case strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(s))
+}
+
+// tidy collapses the separators left by empty template values and trims them from the ends of a path segment
+func tidy(s string) string {
+	for _, sep := range []string{"__", "--", "  "} {
+		for strings.Contains(s, sep) {
+			s = strings.ReplaceAll(s, sep, sep[:1])
+		}
+	}
+	s = strings.Trim(s, "_- ")
+	s = strings.ReplaceAll(s, "_.", ".")
+	return strings.TrimRight(s, ". ")
+}
+
+// pathAllocator hands out unique paths for documents below a directory
+type pathAllocator struct {
+	dir string
+	// taken maps paths to the ID of the document they belong to
+	taken map[string]string
+	// overwrite allows paths occupied by existing files
+	overwrite bool
+}
+
+func newPathAllocator(dir string, s *State) *pathAllocator {
+	a := &pathAllocator{dir: dir, taken: map[string]string{}}
+	for id, e := range s.Documents {
+		a.taken[e.Path] = id
+	}
+	return a
+}
+
+// allocate returns a path relative to the directory for the document id based on rel. A numeric suffix is added if
+// the path belongs to another document or is occupied by a file not created by a sync.
+func (a *pathAllocator) allocate(id string, rel string) string {
+	ext := filepath.Ext(rel)
+	base := strings.TrimSuffix(rel, ext)
+
+	for i := 1; ; i++ {
+		p := filepath.Clean(rel)
+		if i > 1 {
+			p = filepath.Clean(fmt.Sprintf("%s_%d%s", base, i, ext))
+		}
+
+		owner, ok := a.taken[p]
+		if ok && owner != id {
+			continue
+		}
+		if !ok && !a.overwrite {
+			if _, err := os.Stat(filepath.Join(a.dir, p)); !errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+		}
+		a.taken[p] = id
+		return p
+	}
+}
@@ -0,0 +1,88 @@
+package docsync
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry records a document saved by a sync
+type Entry struct {
+	ID       string `json:"id"`
+	Checksum string `json:"checksum"`
+	// Path is relative to the directory synced to, so that it does not depend on the working directory
+	Path    string    `json:"path"`
+	SavedAt time.Time `json:"savedAt"`
+}
+
+// State is the local index of the documents saved by previous syncs, persisted as a JSON file
+type State struct {
+	path      string
+	Documents map[string]Entry `json:"documents"`
+}
+
+// LoadState reads the state from path. An empty state is returned if the file does not exist yet.
+func LoadState(path string) (*State, error) {
+	s := &State{path: path, Documents: map[string]Entry{}}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(b, s)
+	if err != nil {
+		return nil, err
+	}
+	if s.Documents == nil {
+		s.Documents = map[string]Entry{}
+	}
+	return s, nil
+}
+
+// Save writes the state to the file it was loaded from, replacing it atomically
+func (s *State) Save() error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path)
+}
+
+// NeedsDownload reports whether d has not been saved yet, has changed since, or its saved file below dir is missing
+func (s *State) NeedsDownload(d dkbclient.Document, dir string) bool {
+	e, ok := s.Documents[d.ID]
+	if !ok || e.Checksum != d.Attributes.Checksum {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(dir, e.Path))
+	return err != nil
+}
+
+// Record adds d, saved at path relative to the directory synced to, to the state
+func (s *State) Record(d dkbclient.Document, path string) Entry {
+	e := Entry{ID: d.ID, Checksum: d.Attributes.Checksum, Path: path, SavedAt: time.Now().UTC()}
+	s.Documents[d.ID] = e
+	return e
+}
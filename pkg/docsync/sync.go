@@ -0,0 +1,155 @@
+// Package docsync incrementally saves the documents of the DKB document storage to a local directory
+package docsync
+
+import (
+	"context"
+	"errors"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"os"
+	"path/filepath"
+)
+
+// Syncer saves documents to Dir, skipping those already recorded in State
+type Syncer struct {
+	Client *dkbclient.Client
+	State  *State
+	Dir    string
+	// Template determines the path of new documents below Dir; DefaultPathTemplate is used if nil. Documents that
+	// have been saved before keep their path.
+	Template *PathTemplate
+	// Download configures the concurrency and rate limit of the downloads; dkbclient.DefaultDownloadOptions is used
+	// if nil
+	Download *dkbclient.DownloadOptions
+	// MarkRead marks successfully saved documents as read in the mailbox
+	MarkRead bool
+}
+
+// Report describes the outcome of a sync
+type Report struct {
+	Added     []Entry
+	Updated   []Entry
+	Unchanged int
+	// Failed holds the documents that could not be saved or marked as read
+	Failed dkbclient.DocumentErrors
+}
+
+// Sync saves the documents of docs that are new or have changed since the last sync and records them in the
+// state, which is saved afterwards. Failing documents are reported in Report.Failed and do not abort the sync.
+func (s *Syncer) Sync(ctx context.Context, docs []dkbclient.Document) (Report, error) {
+	var r Report
+
+	tmpl := s.Template
+	if tmpl == nil {
+		var err error
+		tmpl, err = ParsePathTemplate(DefaultPathTemplate)
+		if err != nil {
+			return r, err
+		}
+	}
+	opts := dkbclient.DefaultDownloadOptions
+	if s.Download != nil {
+		opts = *s.Download
+	}
+	allocator := newPathAllocator(s.Dir, s.State)
+
+	var pending []dkbclient.Document
+	paths := map[string]string{}
+	for _, d := range docs {
+		if !s.State.NeedsDownload(d, s.Dir) {
+			r.Unchanged++
+			continue
+		}
+
+		if e, ok := s.State.Documents[d.ID]; ok {
+			paths[d.ID] = e.Path
+		} else {
+			rel, err := tmpl.Path(d)
+			if err != nil {
+				r.Failed = append(r.Failed, &dkbclient.DocumentError{DocumentID: d.ID, Err: err})
+				continue
+			}
+			paths[d.ID] = allocator.allocate(d.ID, rel)
+		}
+		pending = append(pending, d)
+	}
+
+	open := func(d dkbclient.Document) (dkbclient.DocumentWriter, error) {
+		return createFile(filepath.Join(s.Dir, paths[d.ID]))
+	}
+	err := s.Client.DownloadDocuments(ctx, pending, open, opts)
+	failed := map[string]bool{}
+	var de dkbclient.DocumentErrors
+	if errors.As(err, &de) {
+		r.Failed = append(r.Failed, de...)
+		for _, e := range de {
+			failed[e.DocumentID] = true
+		}
+	} else if err != nil {
+		return r, err
+	}
+
+	for _, d := range pending {
+		if failed[d.ID] {
+			continue
+		}
+
+		_, known := s.State.Documents[d.ID]
+		e := s.State.Record(d, paths[d.ID])
+		if known {
+			r.Updated = append(r.Updated, e)
+		} else {
+			r.Added = append(r.Added, e)
+		}
+
+		if s.MarkRead && ctx.Err() == nil {
+			if err := s.Client.MarkDocumentRead(ctx, d.ID); err != nil {
+				r.Failed = append(r.Failed, &dkbclient.DocumentError{DocumentID: d.ID, Err: err})
+			}
+		}
+	}
+
+	// the state is saved even if the sync has been canceled, so that the documents saved so far are recorded
+	err = s.State.Save()
+	if err != nil {
+		return r, err
+	}
+	return r, ctx.Err()
+}
+
+// file is a dkbclient.DocumentWriter writing to a temporary file, which replaces the destination on Commit
+type file struct {
+	*os.File
+	path string
+}
+
+func createFile(path string) (*file, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return nil, err
+	}
+	return &file{File: f, path: path}, nil
+}
+
+func (f *file) Commit() error {
+	err := f.File.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	err = os.Rename(f.Name(), f.path)
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func (f *file) Abort() error {
+	f.File.Close()
+	return os.Remove(f.Name())
+}
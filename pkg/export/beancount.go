@@ -0,0 +1,32 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteBeancount writes j to w as beancount directives, with the transaction ID as id metadata. Only booked
+// transactions are written, so that the balance directive holds. No open directives are written for the accounts.
+func WriteBeancount(w io.Writer, j Journal, opts JournalOptions) error {
+	return writeJournal(w, j, func(bw *bufio.Writer, tx Transaction) {
+		fmt.Fprintf(bw, "%s * %s %s\n", tx.BookingDate.Format(dateLayout), beancountString(payee(tx)),
+			beancountString(oneLine(tx.Description)))
+		fmt.Fprintf(bw, "  id: %s\n", beancountString(tx.ID))
+		fmt.Fprintf(bw, "  %-40s  %s\n", j.Account, journalAmount(tx.Amount))
+		fmt.Fprintf(bw, "  %s\n\n", opts.counterAccount(tx))
+	}, func(bw *bufio.Writer, balance dkbclient.Money, date time.Time) {
+		// Beancount checks balances at the beginning of the day
+		fmt.Fprintf(bw, "%s balance %s  %s\n", date.AddDate(0, 0, 1).Format(dateLayout), j.Account,
+			journalAmount(balance))
+	})
+}
+
+var beancountEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func beancountString(s string) string {
+	return `"` + beancountEscaper.Replace(s) + `"`
+}
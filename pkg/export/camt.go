@@ -0,0 +1,265 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"io"
+	"strings"
+	"time"
+)
+
+const camtNamespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// CAMTOptions configures WriteCAMT053
+type CAMTOptions struct {
+	// Time is the creation time of the message and statement; the current time is used if zero
+	Time time.Time
+}
+
+type camtDocument struct {
+	XMLName   xml.Name      `xml:"Document"`
+	Namespace string        `xml:"xmlns,attr"`
+	MsgID     string        `xml:"BkToCstmrStmt>GrpHdr>MsgId"`
+	CreDtTm   string        `xml:"BkToCstmrStmt>GrpHdr>CreDtTm"`
+	Stmt      camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	ID           string        `xml:"Id"`
+	ElctrncSeqNb int           `xml:"ElctrncSeqNb"`
+	CreDtTm      string        `xml:"CreDtTm"`
+	FrDtTm       string        `xml:"FrToDt>FrDtTm"`
+	ToDtTm       string        `xml:"FrToDt>ToDtTm"`
+	IBAN         string        `xml:"Acct>Id>IBAN"`
+	Ccy          string        `xml:"Acct>Ccy"`
+	Owner        *camtParty    `xml:"Acct>Ownr"`
+	Servicer     string        `xml:"Acct>Svcr>FinInstnId>BIC"`
+	Balances     []camtBalance `xml:"Bal"`
+	Entries      []camtEntry   `xml:"Ntry"`
+}
+
+type camtBalance struct {
+	Type      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amt       camtAmount `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	Date      string     `xml:"Dt>Dt"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtEntry struct {
+	Amt         camtAmount             `xml:"Amt"`
+	CdtDbtInd   string                 `xml:"CdtDbtInd"`
+	Status      string                 `xml:"Sts"`
+	BookingDate string                 `xml:"BookgDt>Dt"`
+	ValueDate   string                 `xml:"ValDt>Dt"`
+	AcctSvcrRef string                 `xml:"AcctSvcrRef,omitempty"`
+	BkTxCd      camtBankTransaction    `xml:"BkTxCd"`
+	Details     camtTransactionDetails `xml:"NtryDtls>TxDtls"`
+	AddtlInf    string                 `xml:"AddtlNtryInf,omitempty"`
+}
+
+type camtBankTransaction struct {
+	Domain *camtDomain      `xml:"Domn,omitempty"`
+	Prtry  *camtProprietary `xml:"Prtry,omitempty"`
+}
+
+type camtDomain struct {
+	Code      string `xml:"Cd"`
+	Family    string `xml:"Fmly>Cd"`
+	SubFamily string `xml:"Fmly>SubFmlyCd"`
+}
+
+type camtProprietary struct {
+	Code   string `xml:"Cd"`
+	Issuer string `xml:"Issr,omitempty"`
+}
+
+type camtTransactionDetails struct {
+	Refs         *camtReferences  `xml:"Refs"`
+	Debtor       *camtParty       `xml:"RltdPties>Dbtr"`
+	DebtorAcct   *camtAccount     `xml:"RltdPties>DbtrAcct"`
+	Creditor     *camtParty       `xml:"RltdPties>Cdtr"`
+	CreditorAcct *camtAccount     `xml:"RltdPties>CdtrAcct"`
+	Agents       *camtAgents      `xml:"RltdAgts"`
+	Purpose      *camtProprietary `xml:"Purp"`
+	Remittance   *camtRemittance  `xml:"RmtInf"`
+}
+
+type camtReferences struct {
+	EndToEndID string `xml:"EndToEndId,omitempty"`
+	MandateID  string `xml:"MndtId,omitempty"`
+}
+
+type camtAccount struct {
+	IBAN string `xml:"Id>IBAN"`
+}
+
+type camtAgents struct {
+	Debtor   *camtAgent `xml:"DbtrAgt"`
+	Creditor *camtAgent `xml:"CdtrAgt"`
+}
+
+type camtAgent struct {
+	BIC string `xml:"FinInstnId>BIC"`
+}
+
+type camtRemittance struct {
+	Unstructured string `xml:"Ustrd"`
+}
+
+type camtParty struct {
+	Name string `xml:"Nm,omitempty"`
+	// CreditorID is the SEPA creditor identifier
+	CreditorID *camtCreditorID `xml:"Id>PrvtId>Othr,omitempty"`
+}
+
+type camtCreditorID struct {
+	ID     string `xml:"Id"`
+	Scheme string `xml:"SchmeNm>Prtry"`
+}
+
+// WriteCAMT053 writes s to w as ISO 20022 camt.053.001.02 bank to customer statement
+func WriteCAMT053(w io.Writer, s Statement, opts CAMTOptions) error {
+	now := opts.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	a := s.Account.Attributes
+	id := fmt.Sprintf("%s%05d", s.To.Format("20060102"), s.Number)
+
+	doc := camtDocument{
+		Namespace: camtNamespace,
+		MsgID:     id,
+		CreDtTm:   now.Format(time.RFC3339),
+		Stmt: camtStatement{
+			ID:           id,
+			ElctrncSeqNb: s.Number,
+			CreDtTm:      now.Format(time.RFC3339),
+			FrDtTm:       s.From.Format(dateLayout) + "T00:00:00",
+			ToDtTm:       s.To.Format(dateLayout) + "T23:59:59",
+			IBAN:         strings.ReplaceAll(a.Iban, " ", ""),
+			Ccy:          s.ClosingBalance.Currency,
+			Servicer:     dkbBIC,
+		},
+	}
+
+	if a.HolderName != "" {
+		doc.Stmt.Owner = &camtParty{Name: a.HolderName}
+	}
+
+	for _, b := range []struct {
+		code    string
+		balance dkbclient.Money
+		date    time.Time
+	}{
+		{"PRCD", s.OpeningBalance, s.From},
+		{"CLBD", s.ClosingBalance, s.To},
+	} {
+		doc.Stmt.Balances = append(doc.Stmt.Balances, camtBalance{
+			Type:      b.code,
+			Amt:       newCAMTAmount(b.balance, s.ClosingBalance.Currency),
+			CdtDbtInd: camtCreditDebit(b.balance),
+			Date:      b.date.Format(dateLayout),
+		})
+	}
+
+	for _, tx := range s.Transactions {
+		doc.Stmt.Entries = append(doc.Stmt.Entries, newCAMTEntry(tx, s))
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(doc)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func newCAMTEntry(tx Transaction, s Statement) camtEntry {
+	valueDate := tx.ValueDate
+	if valueDate.IsZero() {
+		valueDate = tx.BookingDate
+	}
+
+	e := camtEntry{
+		Amt:         newCAMTAmount(tx.Amount, s.ClosingBalance.Currency),
+		CdtDbtInd:   camtCreditDebit(tx.Amount),
+		Status:      "BOOK",
+		BookingDate: tx.BookingDate.Format(dateLayout),
+		ValueDate:   valueDate.Format(dateLayout),
+		AcctSvcrRef: truncate(tx.ID, 35),
+		BkTxCd:      camtBankTransactionCode(tx),
+		AddtlInf:    truncate(tx.TransactionType, 500),
+	}
+
+	dt := &e.Details
+	if tx.EndToEndID != "" || tx.MandateID != "" {
+		dt.Refs = &camtReferences{EndToEndID: truncate(tx.EndToEndID, 35), MandateID: truncate(tx.MandateID, 35)}
+	}
+	if tx.PurposeCode != "" {
+		dt.Purpose = &camtProprietary{Code: tx.PurposeCode}
+	}
+	if desc := oneLine(tx.Description); desc != "" {
+		dt.Remittance = &camtRemittance{Unstructured: truncate(desc, 140)}
+	}
+
+	// The account holder is the debtor of outgoing and the creditor of incoming transactions
+	holder := &camtParty{Name: s.Account.Attributes.HolderName}
+	counterparty := &camtParty{Name: truncate(tx.CounterpartyName, 70)}
+	var account *camtAccount
+	if tx.CounterpartyIBAN != "" {
+		account = &camtAccount{IBAN: tx.CounterpartyIBAN}
+	}
+	var agent *camtAgent
+	if tx.CounterpartyBIC != "" {
+		agent = &camtAgent{BIC: tx.CounterpartyBIC}
+	}
+	if tx.Amount.Sign() < 0 {
+		dt.Debtor, dt.Creditor, dt.CreditorAcct = holder, counterparty, account
+		if agent != nil {
+			dt.Agents = &camtAgents{Creditor: agent}
+		}
+		if tx.CreditorID != "" {
+			counterparty.CreditorID = &camtCreditorID{ID: tx.CreditorID, Scheme: "SEPA"}
+		}
+	} else {
+		dt.Debtor, dt.Creditor, dt.DebtorAcct = counterparty, holder, account
+		if agent != nil {
+			dt.Agents = &camtAgents{Debtor: agent}
+		}
+	}
+	return e
+}
+
+// camtBankTransactionCode maps the business transaction code of tx to an ISO bank transaction code if it has the form
+// DOMAIN-FAMILY-SUBFAMILY, and to a proprietary code of the German banking industry otherwise
+func camtBankTransactionCode(tx Transaction) camtBankTransaction {
+	parts := strings.Split(tx.BusinessTransactionCode, "-")
+	if len(parts) == 3 {
+		return camtBankTransaction{Domain: &camtDomain{Code: parts[0], Family: parts[1], SubFamily: parts[2]}}
+	}
+	return camtBankTransaction{Prtry: &camtProprietary{Code: "NTRF+" + mt940GVC(tx), Issuer: "DK"}}
+}
+
+// newCAMTAmount returns the absolute amount of m, in currency if m has none
+func newCAMTAmount(m dkbclient.Money, currency string) camtAmount {
+	return camtAmount{Currency: currencyOr(m.Currency, currency), Value: m.Abs().Value()}
+}
+
+func camtCreditDebit(m dkbclient.Money) string {
+	if m.Sign() < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
@@ -0,0 +1,81 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteCAMT053(t *testing.T) {
+	created := time.Date(2024, 2, 1, 8, 30, 0, 0, time.UTC)
+	s := Statement{
+		Number:         3,
+		From:           date(t, "2024-01-01"),
+		To:             date(t, "2024-01-31"),
+		OpeningBalance: mustParseMoney(t, "-5"),
+		ClosingBalance: mustParseMoney(t, "100"),
+		Transactions: []Transaction{
+			{ID: "1", BookingDate: date(t, "2024-01-10"), Amount: mustParseMoney(t, "120"), Description: "Salary"},
+			{ID: "2", BookingDate: date(t, "2024-01-20"), Amount: mustParseMoney(t, "-15"),
+				CounterpartyName: "Shop", CounterpartyIBAN: "DE02120300000000202051"},
+		},
+	}
+	s.Account.Attributes.Iban = "DE02 1203 0000 0000 2020 51"
+
+	var b bytes.Buffer
+	if err := WriteCAMT053(&b, s, CAMTOptions{Time: created}); err != nil {
+		t.Fatal(err)
+	}
+	var doc camtDocument
+	if err := xml.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatalf("Unmarshal: %v\n%s", err, b.String())
+	}
+
+	if want := created.Format(time.RFC3339); doc.CreDtTm != want || doc.Stmt.CreDtTm != want {
+		t.Errorf("creation time %q and %q, want %q", doc.CreDtTm, doc.Stmt.CreDtTm, want)
+	}
+	if doc.MsgID != "2024013100003" {
+		t.Errorf("message ID %q, want 2024013100003", doc.MsgID)
+	}
+	if doc.Stmt.IBAN != "DE02120300000000202051" {
+		t.Errorf("IBAN %q, want DE02120300000000202051", doc.Stmt.IBAN)
+	}
+
+	balances := []struct{ code, value, indicator, date string }{
+		{"PRCD", "5.00", "DBIT", "2024-01-01"},
+		{"CLBD", "100.00", "CRDT", "2024-01-31"},
+	}
+	if len(doc.Stmt.Balances) != len(balances) {
+		t.Fatalf("%d balances, want %d", len(doc.Stmt.Balances), len(balances))
+	}
+	for i, want := range balances {
+		got := doc.Stmt.Balances[i]
+		if got.Type != want.code || got.Amt.Value != want.value || got.Amt.Currency != "EUR" ||
+			got.CdtDbtInd != want.indicator || got.Date != want.date {
+			t.Errorf("balance %d %+v, want %+v", i, got, want)
+		}
+	}
+
+	entries := []struct{ value, indicator, date string }{
+		{"120.00", "CRDT", "2024-01-10"},
+		{"15.00", "DBIT", "2024-01-20"},
+	}
+	if len(doc.Stmt.Entries) != len(entries) {
+		t.Fatalf("%d entries, want %d", len(doc.Stmt.Entries), len(entries))
+	}
+	for i, want := range entries {
+		got := doc.Stmt.Entries[i]
+		if got.Amt.Value != want.value || got.CdtDbtInd != want.indicator || got.BookingDate != want.date ||
+			got.ValueDate != want.date || got.Status != "BOOK" {
+			t.Errorf("entry %d %+v, want %+v", i, got, want)
+		}
+	}
+	if c := doc.Stmt.Entries[1].Details.CreditorAcct; c == nil || c.IBAN != "DE02120300000000202051" {
+		t.Errorf("creditor account %+v, want DE02120300000000202051", c)
+	}
+	if strings.Contains(b.String(), "<RltdPties></RltdPties>") {
+		t.Errorf("empty related parties written:\n%s", b.String())
+	}
+}
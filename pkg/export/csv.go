@@ -0,0 +1,169 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Column is a column of a CSV export
+type Column string
+
+const (
+	ColumnBookingDate      Column = "booking_date"
+	ColumnValueDate        Column = "value_date"
+	ColumnCounterpartyName Column = "counterparty_name"
+	ColumnCounterpartyIBAN Column = "counterparty_iban"
+	ColumnDescription      Column = "description"
+	ColumnAmount           Column = "amount"
+	ColumnCurrency         Column = "currency"
+	ColumnStatus           Column = "status"
+	ColumnMandateID        Column = "mandate_id"
+	ColumnEndToEndID       Column = "end_to_end_id"
+)
+
+// DefaultColumns are the columns exported if none are configured
+var DefaultColumns = []Column{ColumnBookingDate, ColumnValueDate, ColumnCounterpartyName, ColumnCounterpartyIBAN,
+	ColumnDescription, ColumnAmount, ColumnCurrency, ColumnStatus, ColumnMandateID, ColumnEndToEndID}
+
+// Locale determines the formatting of numbers and dates and the CSV delimiter
+type Locale struct {
+	Delimiter         rune
+	DateLayout        string
+	DecimalSeparator  string
+	ThousandSeparator string
+	// Headers maps columns to their header; the column name is used for missing entries
+	Headers map[Column]string
+}
+
+var (
+	// LocaleGerman uses ";" as delimiter, DD.MM.YYYY dates and amounts like 1.234,56, as expected by German
+	// spreadsheet software
+	LocaleGerman = Locale{
+		Delimiter:         ';',
+		DateLayout:        "02.01.2006",
+		DecimalSeparator:  ",",
+		ThousandSeparator: ".",
+		Headers: map[Column]string{
+			ColumnBookingDate:      "Buchungsdatum",
+			ColumnValueDate:        "Wertstellung",
+			ColumnCounterpartyName: "Zahlungsbeteiligter",
+			ColumnCounterpartyIBAN: "IBAN",
+			ColumnDescription:      "Verwendungszweck",
+			ColumnAmount:           "Betrag",
+			ColumnCurrency:         "Währung",
+			ColumnStatus:           "Status",
+			ColumnMandateID:        "Mandatsreferenz",
+			ColumnEndToEndID:       "Kundenreferenz",
+		},
+	}
+	// LocaleInternational uses "," as delimiter, ISO 8601 dates and amounts like 1234.56
+	LocaleInternational = Locale{
+		Delimiter:        ',',
+		DateLayout:       dateLayout,
+		DecimalSeparator: ".",
+	}
+)
+
+// ParseColumns parses a comma separated list of column names
+func ParseColumns(s string) ([]Column, error) {
+	known := map[Column]bool{}
+	for _, c := range DefaultColumns {
+		known[c] = true
+	}
+
+	var cols []Column
+	for _, name := range strings.Split(s, ",") {
+		c := Column(strings.TrimSpace(name))
+		if !known[c] {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		cols = append(cols, c)
+	}
+	return cols, nil
+}
+
+// CSVOptions configures WriteCSV
+type CSVOptions struct {
+	// Columns are the exported columns in order; DefaultColumns is used if empty
+	Columns []Column
+	Locale  Locale
+}
+
+// WriteCSV writes txs as CSV with a header row to w
+func WriteCSV(w io.Writer, txs []Transaction, opts CSVOptions) error {
+	cols := opts.Columns
+	if len(cols) == 0 {
+		cols = DefaultColumns
+	}
+	l := opts.Locale
+	if l.Delimiter == 0 {
+		l = LocaleInternational
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = l.Delimiter
+
+	record := make([]string, len(cols))
+	for i, c := range cols {
+		record[i] = string(c)
+		if h, ok := l.Headers[c]; ok {
+			record[i] = h
+		}
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+
+	for _, tx := range txs {
+		for i, c := range cols {
+			v, err := l.value(tx, c)
+			if err != nil {
+				return fmt.Errorf("transaction %s: %w", tx.ID, err)
+			}
+			record[i] = v
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// value returns the formatted value of the column c of tx
+func (l Locale) value(tx Transaction, c Column) (string, error) {
+	switch c {
+	case ColumnBookingDate:
+		return l.date(tx.BookingDate), nil
+	case ColumnValueDate:
+		return l.date(tx.ValueDate), nil
+	case ColumnCounterpartyName:
+		return tx.CounterpartyName, nil
+	case ColumnCounterpartyIBAN:
+		return tx.CounterpartyIBAN, nil
+	case ColumnDescription:
+		return tx.Description, nil
+	case ColumnAmount:
+		return tx.Amount.Format(l.DecimalSeparator, l.ThousandSeparator), nil
+	case ColumnCurrency:
+		return tx.Amount.Currency, nil
+	case ColumnStatus:
+		return tx.Status, nil
+	case ColumnMandateID:
+		return tx.MandateID, nil
+	case ColumnEndToEndID:
+		return tx.EndToEndID, nil
+	}
+	return "", fmt.Errorf("unknown column %q", c)
+}
+
+func (l Locale) date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(l.DateLayout)
+}
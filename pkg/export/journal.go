@@ -0,0 +1,139 @@
+package export
+
+import (
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"strings"
+	"time"
+)
+
+// Journal accounts booked against for counterparties not named in JournalOptions.Accounts
+const (
+	DefaultIncomeAccount  = "Income:Unknown"
+	DefaultExpenseAccount = "Expenses:Unknown"
+)
+
+// DefaultTransferAccount is the clearing account transfers between the accounts in JournalOptions.Accounts are booked
+// against
+const DefaultTransferAccount = "Equity:Transfers"
+
+// Journal holds the transactions of an account or card for the plain text accounting exporters
+type Journal struct {
+	// Account is the name of the journal account the transactions are booked on
+	Account string
+	// Balance is the balance of Account at BalanceDate, asserted after the transactions if not nil
+	Balance      *dkbclient.Money
+	BalanceDate  time.Time
+	Transactions []Transaction
+}
+
+// JournalOptions configures the plain text accounting exporters
+type JournalOptions struct {
+	// Accounts maps IBANs, card IDs and masked card numbers of own accounts and cards to journal account names
+	Accounts map[string]string
+	// TransferAccount is booked against for transfers to and from accounts in Accounts, DefaultTransferAccount if
+	// empty. Each side of a transfer is booked against it, so that journals of both accounts can be included together
+	// without counting the transfer twice; the clearing account balances out once both sides are booked.
+	TransferAccount string
+	// IncomeAccount and ExpenseAccount are booked against for counterparties not in Accounts; DefaultIncomeAccount and
+	// DefaultExpenseAccount are used if empty
+	IncomeAccount  string
+	ExpenseAccount string
+}
+
+// AccountJournal returns the journal of acc with the transactions txs, asserting the current balance of acc
+func (o JournalOptions) AccountJournal(acc dkbclient.Account, txs []Transaction) Journal {
+	a := acc.Attributes
+	iban := strings.ReplaceAll(a.Iban, " ", "")
+	name, ok := o.Accounts[iban]
+	if !ok {
+		name = "Assets:DKB:" + accountComponent(iban)
+	}
+	balance := a.Balance
+	balance.Currency = currencyOr(balance.Currency, a.CurrencyCode)
+	return Journal{
+		Account:      name,
+		Balance:      &balance,
+		BalanceDate:  parseDateTime(a.UpdatedAt),
+		Transactions: txs,
+	}
+}
+
+// CreditCardJournal returns the journal of cc with the transactions txs, asserting the current balance of cc
+func (o JournalOptions) CreditCardJournal(cc dkbclient.CreditCard, txs []Transaction) Journal {
+	a := cc.Attributes
+	name, ok := o.Accounts[cc.Id]
+	if !ok {
+		name, ok = o.Accounts[a.MaskedPan]
+	}
+	if !ok {
+		pan := strings.Trim(a.MaskedPan, "*")
+		if len(pan) > 4 {
+			pan = pan[len(pan)-4:]
+		}
+		name = "Liabilities:DKB:Card" + accountComponent(pan)
+	}
+	balance := a.Balance.Money
+	balance.Currency = currencyOr(balance.Currency)
+	return Journal{
+		Account:      name,
+		Balance:      &balance,
+		BalanceDate:  parseDateTime(a.Balance.Date),
+		Transactions: txs,
+	}
+}
+
+// counterAccount returns the journal account tx is booked against
+func (o JournalOptions) counterAccount(tx Transaction) string {
+	if _, ok := o.Accounts[strings.ReplaceAll(tx.CounterpartyIBAN, " ", "")]; ok && tx.CounterpartyIBAN != "" {
+		if o.TransferAccount != "" {
+			return o.TransferAccount
+		}
+		return DefaultTransferAccount
+	}
+	if tx.Amount.Sign() < 0 {
+		if o.ExpenseAccount != "" {
+			return o.ExpenseAccount
+		}
+		return DefaultExpenseAccount
+	}
+	if o.IncomeAccount != "" {
+		return o.IncomeAccount
+	}
+	return DefaultIncomeAccount
+}
+
+// payee returns the counterparty of tx, falling back to its description and type
+func payee(tx Transaction) string {
+	for _, s := range []string{tx.CounterpartyName, tx.Description, tx.TransactionType} {
+		if s = oneLine(s); s != "" {
+			return s
+		}
+	}
+	return "Unknown"
+}
+
+// accountComponent returns s usable as component of an account name, starting with a capital letter or digit and
+// consisting of letters, digits and dashes only
+func accountComponent(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			return r
+		}
+		return -1
+	}, s)
+	if s == "" {
+		return "Unknown"
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// parseDateTime parses s as timestamp or date, returning the zero time if it is neither
+func parseDateTime(s string) time.Time {
+	for _, layout := range []string{time.RFC3339, dateLayout} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,45 @@
+package export
+
+import (
+	"testing"
+)
+
+func TestJournalOptionsCounterAccount(t *testing.T) {
+	own := map[string]string{"DE02120300000000202051": "Assets:Checking", "DE02100100109307118603": "Assets:Savings"}
+	tests := []struct {
+		name string
+		opts JournalOptions
+		tx   Transaction
+		want string
+	}{
+		{
+			name: "transfer to own account",
+			opts: JournalOptions{Accounts: own},
+			tx:   Transaction{CounterpartyIBAN: "DE02 1001 0010 9307 1186 03", Amount: mustParseMoney(t, "-100")},
+			want: DefaultTransferAccount,
+		},
+		{
+			name: "transfer from own account",
+			opts: JournalOptions{Accounts: own, TransferAccount: "Assets:Transit"},
+			tx:   Transaction{CounterpartyIBAN: "DE02120300000000202051", Amount: mustParseMoney(t, "100")},
+			want: "Assets:Transit",
+		},
+		{
+			name: "outgoing",
+			opts: JournalOptions{Accounts: own},
+			tx:   Transaction{CounterpartyIBAN: "DE89370400440532013000", Amount: mustParseMoney(t, "-5")},
+			want: DefaultExpenseAccount,
+		},
+		{
+			name: "incoming",
+			opts: JournalOptions{Accounts: own, IncomeAccount: "Income:Salary"},
+			tx:   Transaction{Amount: mustParseMoney(t, "5")},
+			want: "Income:Salary",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.counterAccount(tt.tx); got != tt.want {
+			t.Errorf("%s: counterAccount = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"io"
+	"sort"
+	"time"
+)
+
+// WriteLedger writes j to w as ledger journal, which hledger reads as well. Each transaction is tagged with its ID.
+// Pending transactions are omitted as they are not part of the asserted balance.
+func WriteLedger(w io.Writer, j Journal, opts JournalOptions) error {
+	return writeJournal(w, j, func(bw *bufio.Writer, tx Transaction) {
+		fmt.Fprintf(bw, "%s * %s\n", tx.BookingDate.Format(dateLayout), payee(tx))
+		fmt.Fprintf(bw, "    ; id: %s\n", tx.ID)
+		if desc := oneLine(tx.Description); desc != "" && desc != payee(tx) {
+			fmt.Fprintf(bw, "    ; description: %s\n", desc)
+		}
+		fmt.Fprintf(bw, "    %-40s  %s\n", j.Account, journalAmount(tx.Amount))
+		fmt.Fprintf(bw, "    %s\n\n", opts.counterAccount(tx))
+	}, func(bw *bufio.Writer, balance dkbclient.Money, date time.Time) {
+		fmt.Fprintf(bw, "%s * Balance assertion\n", date.Format(dateLayout))
+		fmt.Fprintf(bw, "    %-40s  0 %s = %s\n", j.Account, currencyOr(balance.Currency), journalAmount(balance))
+	})
+}
+
+// writeJournal writes the booked transactions of j ordered by booking date using tx, followed by the balance
+// assertion using balance if j has a balance
+func writeJournal(w io.Writer, j Journal, tx func(*bufio.Writer, Transaction),
+	balance func(*bufio.Writer, dkbclient.Money, time.Time)) error {
+	var txs []Transaction
+	for _, t := range j.Transactions {
+		if t.booked() {
+			txs = append(txs, t)
+		}
+	}
+	sort.SliceStable(txs, func(i, k int) bool {
+		return txs[i].BookingDate.Before(txs[k].BookingDate)
+	})
+
+	bw := bufio.NewWriter(w)
+	for _, t := range txs {
+		tx(bw, t)
+	}
+
+	if j.Balance != nil {
+		date := j.BalanceDate
+		if date.IsZero() {
+			date = time.Now()
+		}
+		balance(bw, *j.Balance, date)
+	}
+	return bw.Flush()
+}
+
+// journalAmount formats m with its currency, EUR if it has none
+func journalAmount(m dkbclient.Money) string {
+	return m.Value() + " " + currencyOr(m.Currency)
+}
@@ -0,0 +1,180 @@
+package export
+
+import (
+	"fmt"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	mt940DateLayout = "060102"
+	// mt940SubfieldLen is the maximum length of a subfield of the structured field 86
+	mt940SubfieldLen = 27
+	mt940LineLen     = 65
+)
+
+// gvc codes ("Geschäftsvorfallcodes") used when a transaction has none
+const (
+	gvcDirectDebit    = "105"
+	gvcCreditTransfer = "116"
+	gvcCredit         = "166"
+)
+
+// WriteMT940 writes s to w as SWIFT MT940 statement, with field 86 structured as defined by the German banking
+// industry (DFÜ-Abkommen)
+func WriteMT940(w io.Writer, s Statement) error {
+	var lines []string
+	add := func(format string, a ...any) {
+		lines = append(lines, fmt.Sprintf(format, a...))
+	}
+
+	add(":20:%s%05d", s.To.Format("20060102"), s.Number)
+	add(":25:%s", mt940Account(s.Account.Attributes.Iban))
+	add(":28C:%05d/001", s.Number)
+	add(":60F:%s", mt940Balance(s.OpeningBalance, s.From))
+
+	for _, tx := range s.Transactions {
+		valueDate := tx.ValueDate
+		if valueDate.IsZero() {
+			valueDate = tx.BookingDate
+		}
+		ref := mt940Text(tx.EndToEndID)
+		if ref == "" || ref == "NOTPROVIDED" || len(ref) > 16 {
+			ref = "NONREF"
+		}
+		gvc := mt940GVC(tx)
+		add(":61:%s%s%s%sN%s%s", valueDate.Format(mt940DateLayout), tx.BookingDate.Format("0102"),
+			creditDebit(tx.Amount), tx.Amount.Abs().Format(",", ""), gvc, ref)
+
+		// Lines of field 86 are only wrapped between subfields, so that no line starts with "-" ending the message or
+		// ":" starting a field
+		info := ":86:"
+		for _, f := range mt940Information(tx, gvc) {
+			if len(info)+len(f) > mt940LineLen {
+				lines = append(lines, info)
+				info = ""
+			}
+			info += f
+		}
+		lines = append(lines, info)
+	}
+
+	add(":62F:%s", mt940Balance(s.ClosingBalance, s.To))
+	add("-")
+
+	_, err := io.WriteString(w, strings.Join(lines, "\r\n")+"\r\n")
+	return err
+}
+
+// mt940Information returns the structured content of field 86 of tx, the GVC followed by the subfields
+func mt940Information(tx Transaction, gvc string) []string {
+	fields := []string{gvc}
+	add := func(format string, a ...any) {
+		fields = append(fields, fmt.Sprintf(format, a...))
+	}
+	if tx.TransactionType != "" {
+		add("?00%s", truncate(mt940Text(tx.TransactionType), mt940SubfieldLen))
+	}
+
+	var purpose []string
+	for _, p := range []struct{ key, value string }{
+		{"EREF+", tx.EndToEndID},
+		{"MREF+", tx.MandateID},
+		{"CRED+", tx.CreditorID},
+		{"PURP+", tx.PurposeCode},
+		{"SVWZ+", tx.Description},
+	} {
+		if p.value != "" {
+			purpose = append(purpose, chunk(p.key+oneLine(mt940Text(p.value)), mt940SubfieldLen)...)
+		}
+	}
+	// Purpose subfields are ?20 to ?29 followed by ?60 to ?63
+	var continuation []string
+	for i, p := range purpose {
+		switch {
+		case i < 10:
+			add("?%d%s", 20+i, p)
+		case i < 14:
+			continuation = append(continuation, fmt.Sprintf("?%d%s", 50+i, p))
+		}
+	}
+
+	if tx.CounterpartyBIC != "" {
+		add("?30%s", mt940Text(tx.CounterpartyBIC))
+	}
+	if tx.CounterpartyIBAN != "" {
+		add("?31%s", mt940Text(tx.CounterpartyIBAN))
+	}
+	for i, n := range chunk(mt940Text(tx.CounterpartyName), mt940SubfieldLen) {
+		if i == 2 {
+			break
+		}
+		add("?%d%s", 32+i, n)
+	}
+	return append(fields, continuation...)
+}
+
+// mt940GVC returns the business transaction code of tx, the one of the API if it is a GVC
+func mt940GVC(tx Transaction) string {
+	c := tx.BusinessTransactionCode
+	if len(c) == 3 && strings.Trim(c, "0123456789") == "" {
+		return c
+	}
+	switch {
+	case tx.Amount.Sign() >= 0:
+		return gvcCredit
+	case tx.MandateID != "":
+		return gvcDirectDebit
+	}
+	return gvcCreditTransfer
+}
+
+// mt940Account returns the account identification of field 25, bank code and account number for German IBANs
+func mt940Account(iban string) string {
+	iban = strings.ReplaceAll(iban, " ", "")
+	if strings.HasPrefix(iban, "DE") && len(iban) == 22 {
+		return iban[4:12] + "/" + iban[12:]
+	}
+	return iban
+}
+
+func mt940Balance(balance dkbclient.Money, date time.Time) string {
+	return creditDebit(balance) + date.Format(mt940DateLayout) + balance.Currency + balance.Abs().Format(",", "")
+}
+
+func creditDebit(m dkbclient.Money) string {
+	if m.Sign() < 0 {
+		return "D"
+	}
+	return "C"
+}
+
+var mt940Replacer = strings.NewReplacer("ä", "ae", "ö", "oe", "ü", "ue", "Ä", "Ae", "Ö", "Oe", "Ü", "Ue", "ß", "ss")
+
+// mt940Text returns s restricted to the SWIFT character set, without the subfield separator "?"
+func mt940Text(s string) string {
+	s = mt940Replacer.Replace(s)
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', strings.ContainsRune("/-:().,'+ ", r):
+			return r
+		}
+		return ' '
+	}, s)
+}
+
+// chunk splits s into parts of at most n runes
+func chunk(s string, n int) []string {
+	var parts []string
+	r := []rune(s)
+	for len(r) > n {
+		parts = append(parts, string(r[:n]))
+		r = r[n:]
+	}
+	if len(r) > 0 {
+		parts = append(parts, string(r))
+	}
+	return parts
+}
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"bytes"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"strings"
+	"testing"
+)
+
+func TestWriteMT940Field86(t *testing.T) {
+	tests := []struct {
+		name string
+		tx   Transaction
+	}{
+		{
+			name: "hyphen at wrap position",
+			tx:   Transaction{Description: strings.Repeat("x", 47) + "-" + strings.Repeat("y", 60)},
+		},
+		{
+			name: "colon at wrap position",
+			tx:   Transaction{Description: strings.Repeat("x", 47) + ":" + strings.Repeat("y", 60)},
+		},
+		{
+			name: "all subfields",
+			tx: Transaction{TransactionType: "Lastschrift", EndToEndID: "E2E-1", MandateID: "M:1", CreditorID: "DE98ZZZ09999999999",
+				PurposeCode: "RINP", Description: strings.Repeat("-:", 200), CounterpartyBIC: "BYLADEM1001",
+				CounterpartyIBAN: "DE02120300000000202051", CounterpartyName: strings.Repeat("-Name:", 10)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := tt.tx
+			tx.ID, tx.Status = "1", string(dkbclient.TransactionStatusBooked)
+			tx.BookingDate, tx.Amount = date(t, "2024-01-10"), mustParseMoney(t, "-10")
+			s := Statement{
+				Number:         1,
+				From:           date(t, "2024-01-01"),
+				To:             date(t, "2024-01-31"),
+				OpeningBalance: mustParseMoney(t, "110"),
+				ClosingBalance: mustParseMoney(t, "100"),
+				Transactions:   []Transaction{tx},
+			}
+			var b bytes.Buffer
+			if err := WriteMT940(&b, s); err != nil {
+				t.Fatal(err)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+			if last := lines[len(lines)-1]; last != "-" {
+				t.Errorf("last line %q, want -", last)
+			}
+			var field86 []string
+			in86 := false
+			for i, l := range lines[:len(lines)-1] {
+				if len(l) > mt940LineLen {
+					t.Errorf("line %d %q longer than %d", i, l, mt940LineLen)
+				}
+				if strings.HasPrefix(l, "-") {
+					t.Errorf("line %d %q ends the message", i, l)
+				}
+				tag, _, _ := strings.Cut(strings.TrimPrefix(l, ":"), ":")
+				switch {
+				case !strings.HasPrefix(l, ":"):
+					if !in86 {
+						t.Errorf("line %d %q continues a field other than 86", i, l)
+						continue
+					}
+					field86[len(field86)-1] += l
+				case tag == "86":
+					field86, in86 = append(field86, l), true
+				case tag == "20", tag == "25", tag == "28C", tag == "60F", tag == "61", tag == "62F":
+					in86 = false
+				default:
+					t.Errorf("line %d %q starts unknown field %s", i, l, tag)
+				}
+			}
+
+			want := ":86:" + strings.Join(mt940Information(tx, mt940GVC(tx)), "")
+			if len(field86) != 1 || field86[0] != want {
+				t.Errorf("field 86 %q, want %q", field86, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package export
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n" +
+		`<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+	ofxDateLayout = "20060102150405"
+
+	// dkbBankCode is the German bank code (BLZ) of DKB
+	dkbBankCode = "12030000"
+	dkbBIC      = "BYLADEM1001"
+
+	ofxNameMaxLen  = 32
+	ofxMemoMaxLen  = 255
+	ofxFITIDMaxLen = 255
+)
+
+// OFXOptions configures the OFX writers
+type OFXOptions struct {
+	// Time is the server time of the response; the current time is used if zero
+	Time time.Time
+}
+
+type ofxDocument struct {
+	XMLName xml.Name          `xml:"OFX"`
+	SignOn  ofxSignOnResponse `xml:"SIGNONMSGSRSV1>SONRS"`
+	Bank    *ofxStmtTrnRs     `xml:"BANKMSGSRSV1>STMTTRNRS,omitempty"`
+	Card    *ofxCCStmtTrnRs   `xml:"CREDITCARDMSGSRSV1>CCSTMTTRNRS,omitempty"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOnResponse struct {
+	Status   ofxStatus `xml:"STATUS"`
+	DTServer string    `xml:"DTSERVER"`
+	Language string    `xml:"LANGUAGE"`
+	Org      string    `xml:"FI>ORG"`
+	FID      string    `xml:"FI>FID"`
+}
+
+type ofxStmtTrnRs struct {
+	TrnUID string    `xml:"TRNUID"`
+	Status ofxStatus `xml:"STATUS"`
+	StmtRs struct {
+		CurDef    string      `xml:"CURDEF"`
+		BankID    string      `xml:"BANKACCTFROM>BANKID"`
+		AcctID    string      `xml:"BANKACCTFROM>ACCTID"`
+		AcctType  string      `xml:"BANKACCTFROM>ACCTTYPE"`
+		TranList  ofxTranList `xml:"BANKTRANLIST"`
+		LedgerBal ofxBalance  `xml:"LEDGERBAL"`
+		AvailBal  *ofxBalance `xml:"AVAILBAL,omitempty"`
+	} `xml:"STMTRS"`
+}
+
+type ofxCCStmtTrnRs struct {
+	TrnUID   string    `xml:"TRNUID"`
+	Status   ofxStatus `xml:"STATUS"`
+	CCStmtRs struct {
+		CurDef    string      `xml:"CURDEF"`
+		AcctID    string      `xml:"CCACCTFROM>ACCTID"`
+		TranList  ofxTranList `xml:"BANKTRANLIST"`
+		LedgerBal ofxBalance  `xml:"LEDGERBAL"`
+		AvailBal  *ofxBalance `xml:"AVAILBAL,omitempty"`
+	} `xml:"CCSTMTRS"`
+}
+
+type ofxTranList struct {
+	DTStart      string           `xml:"DTSTART"`
+	DTEnd        string           `xml:"DTEND"`
+	Transactions []ofxTransaction `xml:"STMTTRN"`
+}
+
+type ofxTransaction struct {
+	TrnType  string `xml:"TRNTYPE"`
+	DTPosted string `xml:"DTPOSTED"`
+	DTUser   string `xml:"DTUSER,omitempty"`
+	TrnAmt   string `xml:"TRNAMT"`
+	FITID    string `xml:"FITID"`
+	Name     string `xml:"NAME,omitempty"`
+	Memo     string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	BalAmt string `xml:"BALAMT"`
+	DTAsOf string `xml:"DTASOF"`
+}
+
+// WriteAccountOFX writes acc and the booked transactions of txs as OFX 2.1.1 bank statement to w
+func WriteAccountOFX(w io.Writer, acc dkbclient.Account, txs []Transaction, opts OFXOptions) error {
+	doc := newOFXDocument(opts)
+	a := acc.Attributes
+
+	rs := &ofxStmtTrnRs{TrnUID: "1", Status: ofxStatus{Severity: "INFO"}}
+	rs.StmtRs.CurDef = currencyOr(a.CurrencyCode, a.Balance.Currency)
+	rs.StmtRs.BankID = bankCode(a.Iban)
+	rs.StmtRs.AcctID = a.Iban
+	rs.StmtRs.AcctType = "CHECKING"
+	if strings.Contains(strings.ToLower(a.Product.Type), "saving") {
+		rs.StmtRs.AcctType = "SAVINGS"
+	}
+
+	rs.StmtRs.TranList = newOFXTranList(txs, doc.SignOn.DTServer)
+
+	asOf := parseTimestamp(a.UpdatedAt, doc.SignOn.DTServer)
+	rs.StmtRs.LedgerBal = newOFXBalance(a.Balance, asOf)
+	if a.AvailableBalance.Currency != "" {
+		b := newOFXBalance(a.AvailableBalance, asOf)
+		rs.StmtRs.AvailBal = &b
+	}
+
+	doc.Bank = rs
+	return writeOFX(w, doc)
+}
+
+// WriteCreditCardOFX writes cc and the booked transactions of txs as OFX 2.1.1 credit card statement to w. The
+// available limit is written as available balance.
+func WriteCreditCardOFX(w io.Writer, cc dkbclient.CreditCard, txs []Transaction, opts OFXOptions) error {
+	doc := newOFXDocument(opts)
+	a := cc.Attributes
+
+	rs := &ofxCCStmtTrnRs{TrnUID: "1", Status: ofxStatus{Severity: "INFO"}}
+	rs.CCStmtRs.CurDef = currencyOr(a.Balance.Currency, a.Limit.Currency)
+	rs.CCStmtRs.AcctID = a.MaskedPan
+	if rs.CCStmtRs.AcctID == "" {
+		rs.CCStmtRs.AcctID = cc.Id
+	}
+
+	rs.CCStmtRs.TranList = newOFXTranList(txs, doc.SignOn.DTServer)
+
+	asOf := parseTimestamp(a.Balance.Date, doc.SignOn.DTServer)
+	rs.CCStmtRs.LedgerBal = newOFXBalance(a.Balance.Money, asOf)
+	if a.AvailableLimit.Currency != "" {
+		b := newOFXBalance(a.AvailableLimit, asOf)
+		rs.CCStmtRs.AvailBal = &b
+	}
+
+	doc.Card = rs
+	return writeOFX(w, doc)
+}
+
+func newOFXDocument(opts OFXOptions) ofxDocument {
+	now := opts.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	return ofxDocument{SignOn: ofxSignOnResponse{
+		Status:   ofxStatus{Severity: "INFO"},
+		DTServer: now.UTC().Format(ofxDateLayout),
+		Language: "DEU",
+		Org:      "DKB",
+		FID:      dkbBankCode,
+	}}
+}
+
+// newOFXTranList returns the list of the booked transactions of txs. Pending transactions are left out, as their ID
+// changes once they are booked, which would duplicate them on the next import.
+func newOFXTranList(txs []Transaction, now string) ofxTranList {
+	var l ofxTranList
+	var start, end time.Time
+	for _, tx := range txs {
+		if !tx.booked() {
+			continue
+		}
+		if start.IsZero() || tx.BookingDate.Before(start) {
+			start = tx.BookingDate
+		}
+		if tx.BookingDate.After(end) {
+			end = tx.BookingDate
+		}
+
+		t := ofxTransaction{
+			TrnType:  ofxTransactionType(tx),
+			DTPosted: tx.BookingDate.Format(ofxDateLayout),
+			TrnAmt:   tx.Amount.Value(),
+			FITID:    fitID(tx.ID),
+			Name:     truncate(tx.CounterpartyName, ofxNameMaxLen),
+			Memo:     truncate(oneLine(tx.Description), ofxMemoMaxLen),
+		}
+		if t.Name == "" {
+			t.Name = truncate(oneLine(tx.Description), ofxNameMaxLen)
+		}
+		if !tx.ValueDate.IsZero() {
+			t.DTUser = tx.ValueDate.Format(ofxDateLayout)
+		}
+		l.Transactions = append(l.Transactions, t)
+	}
+	if !start.IsZero() {
+		l.DTStart = start.Format(ofxDateLayout)
+		l.DTEnd = end.Format(ofxDateLayout)
+	} else {
+		l.DTStart, l.DTEnd = now, now
+	}
+	return l
+}
+
+func newOFXBalance(balance dkbclient.Money, asOf string) ofxBalance {
+	return ofxBalance{BalAmt: balance.Value(), DTAsOf: asOf}
+}
+
+func ofxTransactionType(tx Transaction) string {
+	switch {
+	case tx.Amount.Sign() >= 0:
+		return "CREDIT"
+	case tx.MandateID != "":
+		return "DIRECTDEBIT"
+	}
+	return "DEBIT"
+}
+
+// fitID returns a stable financial institution transaction ID for the transaction ID id
+func fitID(id string) string {
+	if len(id) <= ofxFITIDMaxLen {
+		return id
+	}
+	sum := sha1.Sum([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeOFX(w io.Writer, doc ofxDocument) error {
+	_, err := io.WriteString(w, ofxHeader)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(doc)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// bankCode returns the bank code contained in a German IBAN, falling back to the one of DKB
+func bankCode(iban string) string {
+	iban = strings.ReplaceAll(iban, " ", "")
+	if strings.HasPrefix(iban, "DE") && len(iban) == 22 {
+		return iban[4:12]
+	}
+	return dkbBankCode
+}
+
+// parseTimestamp formats the date or timestamp s in OFX format, falling back to def if s cannot be parsed
+func parseTimestamp(s string, def string) string {
+	t := parseDateTime(s)
+	if t.IsZero() {
+		return def
+	}
+	return t.UTC().Format(ofxDateLayout)
+}
+
+func currencyOr(currencies ...string) string {
+	for _, c := range currencies {
+		if c != "" {
+			return c
+		}
+	}
+	return "EUR"
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
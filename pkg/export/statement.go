@@ -0,0 +1,77 @@
+package export
+
+import (
+	"fmt"
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"sort"
+	"time"
+)
+
+// Statement is an account statement covering the transactions booked within a date range
+type Statement struct {
+	Account dkbclient.Account
+	// Number is the sequential number of the statement
+	Number int
+	// From and To are the first and last booking date covered by the statement
+	From           time.Time
+	To             time.Time
+	OpeningBalance dkbclient.Money
+	ClosingBalance dkbclient.Money
+	// Transactions are the booked transactions of the statement, ordered by booking date
+	Transactions []Transaction
+}
+
+// NewStatement creates the statement of acc for the transactions of txs booked between from and to, both inclusive.
+// A zero from starts the statement with the first transaction, a zero to ends it today. The balances are derived from
+// the current balance of acc, so txs must contain all transactions booked since from; pending transactions are
+// ignored.
+func NewStatement(acc dkbclient.Account, txs []Transaction, from, to time.Time) (Statement, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	s := Statement{
+		Account: acc,
+		Number:  1,
+		From:    from,
+		To:      to,
+	}
+
+	// Transactions booked after the statement are reverted from the current balance to get the closing balance
+	closing := acc.Attributes.Balance
+	closing.Currency = currencyOr(closing.Currency, acc.Attributes.CurrencyCode)
+	opening := closing
+	var err error
+	for _, tx := range txs {
+		if !tx.booked() || (!from.IsZero() && dateOf(tx.BookingDate) < dateOf(from)) {
+			continue
+		}
+		if dateOf(tx.BookingDate) > dateOf(to) {
+			closing, err = closing.Sub(tx.Amount)
+		} else {
+			s.Transactions = append(s.Transactions, tx)
+		}
+		if err == nil {
+			opening, err = opening.Sub(tx.Amount)
+		}
+		if err != nil {
+			return Statement{}, fmt.Errorf("transaction %s: %w", tx.ID, err)
+		}
+	}
+	sort.SliceStable(s.Transactions, func(i, j int) bool {
+		return s.Transactions[i].BookingDate.Before(s.Transactions[j].BookingDate)
+	})
+
+	if s.From.IsZero() {
+		s.From = s.To
+		if len(s.Transactions) > 0 {
+			s.From = s.Transactions[0].BookingDate
+		}
+	}
+	s.OpeningBalance, s.ClosingBalance = opening, closing
+	return s, nil
+}
+
+// dateOf returns the date part of t, comparable as string
+func dateOf(t time.Time) string {
+	return t.Format(dateLayout)
+}
@@ -0,0 +1,111 @@
+package export
+
+import (
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"testing"
+	"time"
+)
+
+func mustParseMoney(t *testing.T, value string) dkbclient.Money {
+	t.Helper()
+	m, err := dkbclient.ParseMoney(value, "EUR")
+	if err != nil {
+		t.Fatalf("ParseMoney(%q): %v", value, err)
+	}
+	return m
+}
+
+func date(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := time.Parse(dateLayout, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestNewStatement(t *testing.T) {
+	booked := string(dkbclient.TransactionStatusBooked)
+	pending := string(dkbclient.TransactionStatusPending)
+	tx := func(id, day, amount, status string) Transaction {
+		return Transaction{ID: id, BookingDate: date(t, day), Amount: mustParseMoney(t, amount), Status: status}
+	}
+
+	tests := []struct {
+		name        string
+		txs         []Transaction
+		from, to    string
+		wantIDs     []string
+		wantOpening string
+		wantClosing string
+	}{
+		{
+			name:        "pending transaction with booking date",
+			txs:         []Transaction{tx("b", "2024-01-10", "-10", booked), tx("p", "2024-01-10", "-10", pending)},
+			from:        "2024-01-01",
+			to:          "2024-01-31",
+			wantIDs:     []string{"b"},
+			wantOpening: "110.00",
+			wantClosing: "100.00",
+		},
+		{
+			name: "transactions after the statement",
+			txs: []Transaction{tx("a", "2024-01-05", "20", booked), tx("b", "2024-02-03", "-5.5", booked),
+				tx("c", "2024-01-02", "-1", booked)},
+			from:        "2024-01-01",
+			to:          "2024-01-31",
+			wantIDs:     []string{"c", "a"},
+			wantOpening: "86.50",
+			wantClosing: "105.50",
+		},
+		{
+			name:        "transactions before the statement",
+			txs:         []Transaction{tx("a", "2023-12-31", "50", booked), tx("b", "2024-01-15", "30", booked)},
+			from:        "2024-01-01",
+			to:          "2024-01-31",
+			wantIDs:     []string{"b"},
+			wantOpening: "70.00",
+			wantClosing: "100.00",
+		},
+		{
+			name:        "without from",
+			txs:         []Transaction{tx("a", "2024-01-20", "40", booked), tx("b", "2024-01-10", "-20", booked)},
+			to:          "2024-01-31",
+			wantIDs:     []string{"b", "a"},
+			wantOpening: "80.00",
+			wantClosing: "100.00",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acc := dkbclient.Account{Attributes: dkbclient.AccountAttributes{Balance: mustParseMoney(t, "100")}}
+			var from time.Time
+			if tt.from != "" {
+				from = date(t, tt.from)
+			}
+			s, err := NewStatement(acc, tt.txs, from, date(t, tt.to))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ids []string
+			for _, tx := range s.Transactions {
+				ids = append(ids, tx.ID)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("transactions %v, want %v", ids, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Fatalf("transactions %v, want %v", ids, tt.wantIDs)
+				}
+			}
+			if got := s.OpeningBalance.Value(); got != tt.wantOpening {
+				t.Errorf("opening balance %s, want %s", got, tt.wantOpening)
+			}
+			if got := s.ClosingBalance.Value(); got != tt.wantClosing {
+				t.Errorf("closing balance %s, want %s", got, tt.wantClosing)
+			}
+		})
+	}
+}
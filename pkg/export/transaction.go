@@ -0,0 +1,99 @@
+// Package export converts account and credit card transactions into file formats of bookkeeping and personal finance
+// software
+package export
+
+import (
+	"github.com/pczora/dkbrobot/pkg/dkbclient"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Transaction is an account or credit card transaction in a form common to all exporters
+type Transaction struct {
+	ID string
+	// BookingDate and ValueDate are zero if unknown, e.g. for pending transactions
+	BookingDate time.Time
+	ValueDate   time.Time
+	// Counterparty is the creditor of outgoing and the debtor of incoming account transactions
+	CounterpartyName string
+	CounterpartyIBAN string
+	CounterpartyBIC  string
+	Description      string
+	// Amount is negative for outgoing transactions
+	Amount                  dkbclient.Money
+	Status                  string
+	TransactionType         string
+	BusinessTransactionCode string
+	PurposeCode             string
+	MandateID               string
+	EndToEndID              string
+	CreditorID              string
+}
+
+// FromAccountTransactions converts account transactions
+func FromAccountTransactions(at []dkbclient.AccountTransaction) []Transaction {
+	txs := make([]Transaction, 0, len(at))
+	for _, t := range at {
+		a := t.Attributes
+		tx := Transaction{
+			ID:                      t.Id,
+			BookingDate:             parseDate(a.BookingDate),
+			ValueDate:               parseDate(a.ValueDate),
+			Description:             a.Description,
+			Amount:                  a.Amount,
+			Status:                  a.Status,
+			TransactionType:         a.TransactionType,
+			BusinessTransactionCode: a.BusinessTransactionCode,
+			PurposeCode:             a.PurposeCode,
+			MandateID:               a.MandateId,
+			EndToEndID:              a.EndToEndId,
+			CreditorID:              a.Creditor.Id,
+		}
+		if a.Amount.Sign() < 0 {
+			tx.CounterpartyName = a.Creditor.Name
+			tx.CounterpartyIBAN = a.Creditor.CreditorAccount.Iban
+			tx.CounterpartyBIC = a.Creditor.Agent.Bic
+		} else {
+			tx.CounterpartyName = a.Debtor.Name
+			tx.CounterpartyIBAN = a.Debtor.DebtorAccount.Iban
+			tx.CounterpartyBIC = a.Debtor.Agent.Bic
+		}
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+// FromCreditCardTransactions converts credit card transactions. The authorization date is used as value date.
+func FromCreditCardTransactions(cct []dkbclient.CreditCardTransaction) []Transaction {
+	txs := make([]Transaction, 0, len(cct))
+	for _, t := range cct {
+		a := t.Attributes
+		tx := Transaction{
+			ID:              t.Id,
+			BookingDate:     parseDate(a.BookingDate),
+			Description:     a.Description,
+			Amount:          a.Amount.Money,
+			Status:          a.Status,
+			TransactionType: a.TransactionType,
+		}
+		if !a.AuthorizationDate.IsZero() {
+			tx.ValueDate = a.AuthorizationDate
+		}
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+// booked reports whether t has been booked; pending transactions may already carry a booking date
+func (t Transaction) booked() bool {
+	return t.Status == string(dkbclient.TransactionStatusBooked) && !t.BookingDate.IsZero()
+}
+
+func parseDate(s string) time.Time {
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}